@@ -9,11 +9,45 @@ import type {
 
 const BASE = '/v1';
 
+// Dashboard API key (read scope; write too for sending test events).
+// Required unless the API runs with AUTH_ENABLED=false.
+const API_KEY = import.meta.env.VITE_API_KEY;
+
+// ApiError is a failed request, carrying the code and request ID of the
+// API's error envelope: {"error": {"code", "message", "request_id", "details"}}.
+export class ApiError extends Error {
+  constructor(
+    message: string,
+    readonly status: number,
+    readonly code?: string,
+    readonly requestId?: string,
+    readonly details?: unknown,
+  ) {
+    super(message);
+    this.name = 'ApiError';
+  }
+}
+
 async function request<T>(url: string, init?: RequestInit): Promise<T> {
-  const res = await fetch(url, init);
+  const headers = new Headers(init?.headers);
+  if (API_KEY) headers.set('Authorization', `Bearer ${API_KEY}`);
+  const res = await fetch(url, { ...init, headers });
   if (!res.ok) {
-    const text = await res.text().catch(() => res.statusText);
-    throw new Error(text || `HTTP ${res.status}`);
+    const text = await res.text().catch(() => '');
+    let body: { error?: { code?: string; message?: string; request_id?: string; details?: unknown } } = {};
+    try {
+      body = JSON.parse(text);
+    } catch {
+      // not an error envelope, e.g. from a proxy
+    }
+    const e = body.error;
+    throw new ApiError(
+      e?.message || text || res.statusText || `HTTP ${res.status}`,
+      res.status,
+      e?.code,
+      e?.request_id ?? res.headers.get('X-Request-ID') ?? undefined,
+      e?.details,
+    );
   }
   return res.json();
 }
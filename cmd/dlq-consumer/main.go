@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/config"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/messaging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/segmentio/kafka-go"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+
+	cfg := config.Load()
+	cfg.ServiceName = "dlq-consumer"
+	logger := logging.New(cfg.ServiceName, cfg.LogLevel)
+
+	retryCfg := messaging.DefaultRetryConfig()
+
+	// ── Connect to PostgreSQL ──────────────────────────────────
+	db, err := storage.New(cfg.DatabaseDSN)
+	if err != nil {
+		logger.Error("failed to connect to postgres", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
+	defer db.Close()
+	logger.Info("connected to postgres", map[string]any{})
+
+	// ── Create DLQ topic consumer ──────────────────────────────
+	consumer := messaging.NewConsumer(cfg.KafkaBrokers, cfg.KafkaDLQTopic, cfg.KafkaDLQGroupID)
+	defer consumer.Close()
+	logger.Info("dlq consumer started", map[string]any{
+		"brokers": cfg.KafkaBrokers,
+		"topic":   cfg.KafkaDLQTopic,
+		"group":   cfg.KafkaDLQGroupID,
+	})
+
+	// ── Graceful shutdown ──────────────────────────────────────
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-sigCh
+		logger.Info("shutdown signal received", map[string]any{"signal": sig.String()})
+		cancel()
+	}()
+
+	// ── Consume loop ───────────────────────────────────────────
+	for {
+		msg, err := consumer.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.Info("dlq consumer shutting down", map[string]any{})
+				return
+			}
+			logger.Error("fetch failed", map[string]any{"error": err.Error()})
+			continue
+		}
+
+		persistEnvelope(ctx, logger, db, consumer, retryCfg, msg)
+	}
+}
+
+// persistEnvelope decodes a DLQ envelope and stores it in dlq_events.
+//
+// The DLQ is the end of the line, so there is nowhere further to route a
+// message that cannot be stored. Transient DB failures are retried until
+// they succeed or the consumer shuts down (leaving the offset uncommitted);
+// undecodable envelopes and permanent failures are logged and skipped.
+func persistEnvelope(
+	ctx context.Context,
+	logger *logging.Logger,
+	db *storage.DB,
+	consumer *messaging.Consumer,
+	retryCfg messaging.RetryConfig,
+	msg kafka.Message,
+) {
+	var env messaging.DLQMessage
+	if err := json.Unmarshal(msg.Value, &env); err != nil {
+		logger.Error("CRITICAL: undecodable DLQ envelope, skipping", map[string]any{
+			"error":     err.Error(),
+			"offset":    msg.Offset,
+			"partition": msg.Partition,
+			"raw_size":  len(msg.Value),
+		})
+		commitAndLog(ctx, logger, consumer, msg)
+		return
+	}
+
+	evtType, tenantID := originalEvent(env.OriginalValue)
+	row := storage.DLQEvent{
+		TenantID:          tenantID,
+		OriginalTopic:     env.OriginalTopic,
+		OriginalPartition: env.OriginalPartition,
+		OriginalOffset:    env.OriginalOffset,
+		OriginalKey:       env.OriginalKey,
+		OriginalValue:     env.OriginalValue,
+		EventType:         evtType,
+		ErrorMessage:      env.ErrorMessage,
+		ErrorKind:         env.ErrorKind,
+		Retries:           env.Retries,
+		FailedAt:          env.FailedAt,
+	}
+
+	for attempt := 0; ; attempt++ {
+		dbCtx, dbCancel := context.WithTimeout(ctx, 5*time.Second)
+		err := db.InsertDLQEvent(dbCtx, row)
+		dbCancel()
+
+		if err == nil {
+			commitAndLog(ctx, logger, consumer, msg)
+			logger.Info("dlq envelope persisted", map[string]any{
+				"original_topic":     env.OriginalTopic,
+				"original_partition": env.OriginalPartition,
+				"original_offset":    env.OriginalOffset,
+				"error_kind":         env.ErrorKind,
+				"attempts":           attempt + 1,
+			})
+			return
+		}
+
+		kind := messaging.Classify(err)
+		logger.Error("dlq insert failed", map[string]any{
+			"error":           err.Error(),
+			"error_kind":      kind.String(),
+			"attempt":         attempt + 1,
+			"original_offset": env.OriginalOffset,
+		})
+
+		if kind == messaging.ErrPermanent {
+			logger.Error("CRITICAL: dropping unstorable DLQ envelope", map[string]any{
+				"offset":    msg.Offset,
+				"partition": msg.Partition,
+			})
+			commitAndLog(ctx, logger, consumer, msg)
+			return
+		}
+
+		if err := retryCfg.Sleep(ctx, attempt); err != nil {
+			logger.Info("retry sleep interrupted by shutdown", map[string]any{
+				"offset": msg.Offset,
+			})
+			return
+		}
+	}
+}
+
+// originalEvent extracts event_type and tenant_id from the original
+// message value so the DLQ can be browsed by type and scoped to its
+// tenant. Unparseable values yield "", which stores under the default
+// tenant.
+func originalEvent(value json.RawMessage) (eventType, tenantID string) {
+	var evt struct {
+		EventType string `json:"event_type"`
+		TenantID  string `json:"tenant_id"`
+	}
+	if err := json.Unmarshal(value, &evt); err != nil {
+		return "", ""
+	}
+	return evt.EventType, evt.TenantID
+}
+
+// commitAndLog commits the offset so the consumer moves past the message.
+func commitAndLog(
+	ctx context.Context,
+	logger *logging.Logger,
+	consumer *messaging.Consumer,
+	msg kafka.Message,
+) {
+	if err := consumer.CommitMessage(ctx, msg); err != nil {
+		logger.Error("offset commit failed", map[string]any{
+			"error":     err.Error(),
+			"offset":    msg.Offset,
+			"partition": msg.Partition,
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/config"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/messaging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/replay"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tenant"
+)
+
+// runReplay implements the "replay" subcommand:
+//
+//	dlq-consumer replay -id 42
+//	dlq-consumer replay -error-kind transient -type click -limit 500
+//	dlq-consumer replay -tenant acme -limit 100
+//
+// It mirrors POST /v1/dlq/{id}/replay and POST /v1/dlq/replay and prints
+// the per-event results as JSON. Returns the process exit code.
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	id := fs.Int64("id", 0, "replay a single DLQ event by id")
+	errorKind := fs.String("error-kind", "", "filter: transient | permanent")
+	topic := fs.String("topic", "", "filter: original topic")
+	eventType := fs.String("type", "", "filter: event type")
+	from := fs.String("from", "", "filter: failed_at >= RFC3339 timestamp")
+	to := fs.String("to", "", "filter: failed_at <= RFC3339 timestamp")
+	includeReplayed := fs.Bool("include-replayed", false, "also select events that were replayed before")
+	limit := fs.Int("limit", 100, "maximum number of events to replay")
+	force := fs.Bool("force", false, "replay events that failed permanently")
+	tenantID := fs.String("tenant", "", "only replay this tenant's events (default: all tenants)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	filter := storage.DLQFilter{
+		ErrorKind:     *errorKind,
+		OriginalTopic: *topic,
+		EventType:     *eventType,
+	}
+	var err error
+	if filter.From, err = parseTime(*from); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -from: %v\n", err)
+		return 2
+	}
+	if filter.To, err = parseTime(*to); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -to: %v\n", err)
+		return 2
+	}
+	if !*includeReplayed {
+		replayed := false
+		filter.Replayed = &replayed
+	}
+
+	cfg := config.Load()
+
+	db, err := storage.New(cfg.DatabaseDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect to postgres: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	producer := messaging.NewProducer(cfg.KafkaBrokers, cfg.KafkaTopic)
+	defer producer.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	if *tenantID != "" {
+		ctx = tenant.With(ctx, *tenantID)
+	} else {
+		ctx = tenant.WithAll(ctx)
+	}
+
+	replayer := replay.New(db, producer)
+
+	var results []replay.Result
+	if *id != 0 {
+		res, _ := replayer.ReplayOne(ctx, *id, *force)
+		results = []replay.Result{res}
+	} else {
+		results, err = replayer.ReplayMatching(ctx, filter, *limit, *force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "query dlq events: %v\n", err)
+			return 1
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(results)
+
+	for _, res := range results {
+		if res.Status == replay.StatusFailed {
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseTime parses an optional RFC3339 flag value; "" yields nil.
+func parseTime(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/health"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+)
+
+// serveAdmin exposes /healthz, /readyz and /metrics on port so
+// orchestrators can probe and scrape the consumer. It returns nil if port
+// is empty.
+func serveAdmin(port string, logger *logging.Logger) *http.Server {
+	if port == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", health.Liveness)
+	mux.HandleFunc("GET /readyz", health.Readiness)
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	srv := &http.Server{Addr: ":" + port, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("admin server stopped", map[string]any{"error": err.Error()})
+		}
+	}()
+	logger.Info("admin endpoints listening", map[string]any{"addr": srv.Addr})
+	return srv
+}
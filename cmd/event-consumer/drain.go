@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// commitTimeout bounds each offset commit. Commits detach from the work
+// context so messages settled just before the drain deadline still get
+// their offsets committed.
+const commitTimeout = 5 * time.Second
+
+func commitContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), commitTimeout)
+}
+
+// consumerStats counts message outcomes for the shutdown report.
+type consumerStats struct {
+	persisted    atomic.Int64
+	retried      atomic.Int64 // handed to a retry tier
+	deadLettered atomic.Int64
+	abandoned    atomic.Int64 // left uncommitted for redelivery
+}
+
+var stats consumerStats
+
+// report summarises the run for the final log line. drainStart is zero
+// if the consumer stopped without a signal.
+func (s *consumerStats) report(drainStart time.Time, deadlineHit bool) map[string]any {
+	fields := map[string]any{
+		"persisted":     s.persisted.Load(),
+		"retried":       s.retried.Load(),
+		"dead_lettered": s.deadLettered.Load(),
+		"abandoned":     s.abandoned.Load(),
+		"drain_expired": deadlineHit,
+	}
+	if !drainStart.IsZero() {
+		fields["drain_duration_ms"] = time.Since(drainStart).Milliseconds()
+	}
+	return fields
+}
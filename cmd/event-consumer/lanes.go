@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/messaging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/segmentio/kafka-go"
+)
+
+// laneBuffer is how many fetched messages each lane may queue before the
+// fetch loop blocks on it.
+const laneBuffer = 64
+
+// runLanes consumes until fetchCtx is cancelled, spreading messages over n
+// lanes by key hash. Each lane processes its messages in order, so events
+// with the same key keep their order while a retry backoff on one lane
+// does not stall the others. Lanes finish out of order; the offset
+// tracker only commits each partition up to its lowest contiguous
+// processed offset. Messages already queued on a lane when fetching
+// stops are still processed until workCtx is cancelled.
+func runLanes(
+	fetchCtx, workCtx context.Context,
+	logger *logging.Logger,
+	db *storage.DB,
+	consumer *messaging.Consumer,
+	dlq *messaging.DLQProducer,
+	retryCfg messaging.RetryConfig,
+	retry *messaging.RetryRouter,
+	n int,
+) {
+	tracker := messaging.NewOffsetTracker()
+	done := make(chan kafka.Message, n*laneBuffer)
+
+	var wg sync.WaitGroup
+	lanes := make([]chan kafka.Message, n)
+	for i := range lanes {
+		lanes[i] = make(chan kafka.Message, laneBuffer)
+		wg.Add(1)
+		go func(lane <-chan kafka.Message) {
+			defer wg.Done()
+			for msg := range lane {
+				if !settleMessage(workCtx, logger, db, dlq, retryCfg, retry, msg) {
+					stats.abandoned.Add(1) // left uncommitted
+					continue
+				}
+				done <- msg
+			}
+		}(lanes[i])
+	}
+
+	// A single committer keeps commits for a partition in offset order.
+	committed := make(chan struct{})
+	go func() {
+		defer close(committed)
+		for msg := range done {
+			if commit, ok := tracker.Done(msg); ok {
+				commitAndLog(workCtx, logger, consumer, commit, "processed")
+			}
+		}
+	}()
+
+	for fetchCtx.Err() == nil {
+		msg, err := consumer.FetchMessage(fetchCtx)
+		if err != nil {
+			if fetchCtx.Err() == nil {
+				logger.Error("fetch failed", map[string]any{"error": err.Error()})
+			}
+			continue
+		}
+
+		tracker.Track(msg)
+		select {
+		case lanes[laneFor(msg, n)] <- msg:
+		case <-fetchCtx.Done():
+			stats.abandoned.Add(1) // fetched but never dispatched
+		}
+	}
+
+	for _, lane := range lanes {
+		close(lane)
+	}
+	wg.Wait()
+	close(done)
+	<-committed
+
+	if n := tracker.Pending(); n > 0 {
+		logger.Info("uncommitted messages will be redelivered", map[string]any{"messages": n})
+	}
+}
+
+// laneFor picks the lane for msg: by key, so per-key order holds, or by
+// partition for keyless messages.
+func laneFor(msg kafka.Message, n int) int {
+	h := fnv.New32a()
+	if len(msg.Key) > 0 {
+		h.Write(msg.Key)
+	} else {
+		fmt.Fprintf(h, "%s/%d", msg.Topic, msg.Partition)
+	}
+	return int(h.Sum32() % uint32(n))
+}
@@ -3,28 +3,55 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/config"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/health"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/messaging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type event struct {
-	EventID   string          `json:"event_id"`
-	EventType string          `json:"event_type"`
-	Payload   json.RawMessage `json:"payload"`
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    *time.Time      `json:"occurred_at,omitempty"`
+	SchemaVersion int             `json:"schema_version,omitempty"` // set by the API when a schema validated the payload
+	TenantID      string          `json:"tenant_id,omitempty"`      // set by the API from the caller's key
+}
+
+// row converts the wire event into a storage row.
+func (e event) row() storage.Event {
+	row := storage.Event{EventID: e.EventID, TenantID: e.TenantID, EventType: e.EventType, Payload: e.Payload, OccurredAt: e.OccurredAt}
+	if e.SchemaVersion > 0 {
+		v := e.SchemaVersion
+		row.SchemaVersion = &v
+	}
+	return row
 }
 
 func main() {
 	cfg := config.Load()
 	cfg.ServiceName = "event-consumer"
-	logger := logging.New(cfg.ServiceName)
+	logger := logging.New(cfg.ServiceName, cfg.LogLevel)
+
+	// ── Tracing ────────────────────────────────────────────────
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.ServiceName, cfg.TraceExporter)
+	if err != nil {
+		logger.Error("invalid TRACE_EXPORTER", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
 
 	// ── Retry config ───────────────────────────────────────────
 	retryCfg := messaging.DefaultRetryConfig()
@@ -49,41 +76,143 @@ func main() {
 	})
 
 	// ── Create DLQ producer ────────────────────────────────────
-	dlq := messaging.NewDLQProducer(cfg.KafkaBrokers, cfg.KafkaDLQTopic)
-	defer dlq.Close()
+	dlq := messaging.NewDLQProducer(cfg.KafkaBrokers, cfg.KafkaDLQTopic) // closed after the drain
 	logger.Info("DLQ producer ready", map[string]any{"dlq_topic": cfg.KafkaDLQTopic})
 
+	// ── Retry tiers ────────────────────────────────────────────
+	tiers, err := messaging.ParseRetryTiers(cfg.KafkaTopic, cfg.RetryTiers)
+	if err != nil {
+		logger.Error("invalid RETRY_TIERS", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
+	var retry *messaging.RetryRouter
+	if len(tiers) > 0 {
+		retry = messaging.NewRetryRouter(cfg.KafkaBrokers, tiers) // closed after the drain
+	}
+
+	// ── Admin endpoints ────────────────────────────────────────
+	health.Register("postgres", db.Ping, health.CheckOptions{})
+	health.Register("kafka", func(ctx context.Context) error {
+		return messaging.CheckBrokers(ctx, cfg.KafkaBrokers, cfg.KafkaTopic)
+	}, health.CheckOptions{Timeout: 3 * time.Second, CacheTTL: 5 * time.Second})
+	if cfg.ConsumerMaxLag > 0 {
+		health.Register("consumer_lag", func(context.Context) error {
+			if lag := consumer.Lag(); lag > int64(cfg.ConsumerMaxLag) {
+				return fmt.Errorf("lag %d exceeds %d", lag, cfg.ConsumerMaxLag)
+			}
+			return nil
+		}, health.CheckOptions{})
+	}
+	adminSrv := serveAdmin(cfg.AdminPort, logger)
+
 	// ── Graceful shutdown ──────────────────────────────────────
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// The first signal stops fetching; in-flight work keeps workCtx until
+	// it finishes or the drain deadline (or a second signal) abandons it.
+	fetchCtx, stopFetching := context.WithCancel(context.Background())
+	defer stopFetching()
+	workCtx, abandon := context.WithCancel(context.Background())
+	defer abandon()
 
-	sigCh := make(chan os.Signal, 1)
+	sigCh := make(chan os.Signal, 2)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
+	var drainStart time.Time
 	go func() {
 		sig := <-sigCh
-		logger.Info("shutdown signal received", map[string]any{"signal": sig.String()})
-		cancel()
+		drainStart = time.Now()
+		logger.Info("shutdown signal received, draining", map[string]any{
+			"signal":        sig.String(),
+			"drain_timeout": cfg.ShutdownTimeout.String(),
+		})
+		health.StartDraining()
+		stopFetching()
+
+		timer := time.NewTimer(cfg.ShutdownTimeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			logger.Info("drain deadline reached, abandoning in-flight work", map[string]any{})
+		case sig := <-sigCh:
+			logger.Info("second signal received, abandoning in-flight work", map[string]any{"signal": sig.String()})
+		}
+		abandon()
 	}()
 
+	// ── Delayed retry workers ──────────────────────────────────
+	var tierWG sync.WaitGroup
+	for _, tier := range tiers {
+		tierWG.Add(1)
+		go func() {
+			defer tierWG.Done()
+			runRetryTier(fetchCtx, workCtx, logger, db, dlq, retryCfg, retry, cfg.KafkaBrokers, cfg.KafkaGroupID, tier)
+		}()
+	}
+
 	// ── Consume loop ───────────────────────────────────────────
-	logger.Info("consuming events", map[string]any{})
-
-	for {
-		// 1. Fetch message (blocks until available or context cancelled)
-		msg, err := consumer.FetchMessage(ctx)
-		if err != nil {
-			if ctx.Err() != nil {
-				logger.Info("consumer shutting down", map[string]any{})
-				return
+	switch {
+	case cfg.ConsumerBatchSize > 1:
+		if cfg.ConsumerConcurrency > 1 {
+			logger.Info("CONSUMER_CONCURRENCY is ignored when batching is enabled", map[string]any{})
+		}
+		size := min(cfg.ConsumerBatchSize, storage.MaxInsertBatch)
+		logger.Info("consuming events in batches", map[string]any{
+			"batch_size": size,
+			"batch_wait": cfg.ConsumerBatchWait.String(),
+		})
+		for {
+			batch, err := consumer.FetchBatch(fetchCtx, size, cfg.ConsumerBatchWait)
+			if err != nil {
+				if fetchCtx.Err() != nil {
+					break
+				}
+				logger.Error("fetch failed", map[string]any{"error": err.Error()})
+				continue
 			}
-			logger.Error("fetch failed", map[string]any{"error": err.Error()})
-			continue
+			// A batch cut short by shutdown is still finished.
+			processBatch(workCtx, logger, db, consumer, dlq, retryCfg, retry, batch)
+		}
+
+	case cfg.ConsumerConcurrency > 1:
+		logger.Info("consuming events on parallel lanes", map[string]any{"lanes": cfg.ConsumerConcurrency})
+		runLanes(fetchCtx, workCtx, logger, db, consumer, dlq, retryCfg, retry, cfg.ConsumerConcurrency)
+
+	default:
+		logger.Info("consuming events", map[string]any{})
+		for {
+			// 1. Fetch message (blocks until available or shutdown)
+			msg, err := consumer.FetchMessage(fetchCtx)
+			if err != nil {
+				if fetchCtx.Err() != nil {
+					break
+				}
+				logger.Error("fetch failed", map[string]any{"error": err.Error()})
+				continue
+			}
+
+			// 2. Process with retry + DLQ routing
+			processMessage(workCtx, logger, db, consumer, dlq, retryCfg, retry, msg)
 		}
+	}
 
-		// 2. Process with retry + DLQ routing
-		processMessage(ctx, logger, db, consumer, dlq, retryCfg, msg)
+	// ── Drain ──────────────────────────────────────────────────
+	tierWG.Wait()
+	if err := dlq.Close(); err != nil {
+		logger.Error("DLQ writer flush failed", map[string]any{"error": err.Error()})
+	}
+	if retry != nil {
+		if err := retry.Close(); err != nil {
+			logger.Error("retry writer flush failed", map[string]any{"error": err.Error()})
+		}
 	}
+	if adminSrv != nil {
+		adminSrv.Close()
+	}
+	traceCtx, traceCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(traceCtx); err != nil {
+		logger.Error("trace flush failed", map[string]any{"error": err.Error()})
+	}
+	traceCancel()
+	logger.Info("consumer shut down", stats.report(drainStart, workCtx.Err() != nil))
 }
 
 // processMessage handles deserialization, persistence, retry, and DLQ routing.
@@ -94,8 +223,139 @@ func processMessage(
 	consumer *messaging.Consumer,
 	dlq *messaging.DLQProducer,
 	retryCfg messaging.RetryConfig,
+	retry *messaging.RetryRouter,
+	msg kafka.Message,
+) {
+	if !settleMessage(ctx, logger, db, dlq, retryCfg, retry, msg) {
+		stats.abandoned.Add(1)
+		return
+	}
+	commitAndLog(ctx, logger, consumer, msg, "processed")
+}
+
+// settleMessage persists a message or routes it to the DLQ without
+// committing. It returns false if shutdown interrupted it, in which case
+// the offset must not be committed.
+func settleMessage(
+	ctx context.Context,
+	logger *logging.Logger,
+	db *storage.DB,
+	dlq *messaging.DLQProducer,
+	retryCfg messaging.RetryConfig,
+	retry *messaging.RetryRouter,
 	msg kafka.Message,
+) bool {
+	if ctx.Err() != nil {
+		return false // drain deadline passed before it started
+	}
+	ctx, span := messaging.StartProcessSpan(ctx, msg)
+	defer span.End()
+	logger = logger.WithContext(ctx)
+
+	evt, ok := decodeEvent(ctx, logger, dlq, msg)
+	if !ok {
+		return true // poison pill, already in the DLQ
+	}
+	return persistEvent(ctx, logger, db, dlq, retryCfg, retry, msg, evt)
+}
+
+// processBatch persists a batch with one multi-row insert and commits all
+// of its offsets together. If the insert fails, each row is retried on its
+// own so a single bad row ends up in the DLQ instead of blocking the rest.
+func processBatch(
+	ctx context.Context,
+	logger *logging.Logger,
+	db *storage.DB,
+	consumer *messaging.Consumer,
+	dlq *messaging.DLQProducer,
+	retryCfg messaging.RetryConfig,
+	retry *messaging.RetryRouter,
+	batch []kafka.Message,
 ) {
+	if ctx.Err() != nil {
+		stats.abandoned.Add(int64(len(batch)))
+		return
+	}
+	ctx, span := startBatchSpan(ctx, batch)
+	defer span.End()
+	logger = logger.WithContext(ctx)
+
+	var msgs []kafka.Message
+	var evts []event
+	var rows []storage.Event
+	for _, msg := range batch {
+		evt, ok := decodeEvent(ctx, logger, dlq, msg)
+		if !ok {
+			continue
+		}
+		msgs = append(msgs, msg)
+		evts = append(evts, evt)
+		rows = append(rows, evt.row())
+	}
+
+	if len(rows) > 0 {
+		dbCtx, dbCancel := context.WithTimeout(ctx, 5*time.Second)
+		err := db.InsertEvents(dbCtx, rows)
+		dbCancel()
+
+		if err == nil {
+			stats.persisted.Add(int64(len(rows)))
+			logger.Info("event batch persisted", map[string]any{
+				"events":   len(rows),
+				"messages": len(batch),
+			})
+		} else if ctx.Err() != nil {
+			stats.abandoned.Add(int64(len(batch)))
+			return // drain deadline: leave the batch uncommitted
+		} else {
+			logger.Warn("batch insert failed, falling back to per-row inserts", map[string]any{
+				"events":     len(rows),
+				"error":      err.Error(),
+				"error_kind": messaging.Classify(err).String(),
+			})
+			for i := range msgs {
+				if !persistEvent(ctx, logger, db, dlq, retryCfg, retry, msgs[i], evts[i]) {
+					stats.abandoned.Add(int64(len(batch)))
+					return // drain deadline: leave the batch uncommitted
+				}
+			}
+		}
+	}
+
+	commitCtx, commitCancel := commitContext(ctx)
+	defer commitCancel()
+	if err := consumer.CommitMessages(commitCtx, batch...); err != nil {
+		logger.Error("batch offset commit failed (events already in DB)", map[string]any{
+			"error":    err.Error(),
+			"messages": len(batch),
+		})
+	}
+}
+
+// startBatchSpan starts one consumer span for a batch, linked to the span
+// that published each message.
+func startBatchSpan(ctx context.Context, batch []kafka.Message) (context.Context, trace.Span) {
+	links := make([]trace.Link, 0, len(batch))
+	for _, msg := range batch {
+		if sc := trace.SpanContextFromContext(messaging.ExtractTrace(ctx, msg)); sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+	return tracing.Tracer().Start(ctx, "process "+batch[0].Topic+" batch",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("messaging.batch.message_count", len(batch))),
+	)
+}
+
+// decodeEvent parses a message and checks required fields. Poison pills
+// are routed to the DLQ and reported as !ok; the caller still commits them.
+func decodeEvent(
+	ctx context.Context,
+	logger *logging.Logger,
+	dlq *messaging.DLQProducer,
+	msg kafka.Message,
+) (event, bool) {
 	// ── Poison pill check: unmarshal ───────────────────────────
 	var evt event
 	if err := json.Unmarshal(msg.Value, &evt); err != nil {
@@ -106,8 +366,7 @@ func processMessage(
 			"raw_size":  len(msg.Value),
 		})
 		sendToDLQ(ctx, logger, dlq, msg, err, messaging.ErrPermanent, 0)
-		commitAndLog(ctx, logger, consumer, msg, "poison-pill")
-		return
+		return event{}, false
 	}
 
 	// ── Poison pill check: required fields ─────────────────────
@@ -118,32 +377,52 @@ func processMessage(
 			"partition": msg.Partition,
 		})
 		sendToDLQ(ctx, logger, dlq, msg, err, messaging.ErrPermanent, 0)
-		commitAndLog(ctx, logger, consumer, msg, "poison-pill-missing-fields")
-		return
+		return event{}, false
 	}
+	return evt, true
+}
 
+// persistEvent inserts one event with bounded retry, routing it to the DLQ
+// on a permanent error or once the retry budget is spent. With retry
+// topics configured, a transient failure moves the message to the next
+// retry tier instead of sleeping here, and to the DLQ after the last tier.
+// It returns true when the message is settled and its offset may be
+// committed, and false if shutdown interrupted the retries.
+func persistEvent(
+	ctx context.Context,
+	logger *logging.Logger,
+	db *storage.DB,
+	dlq *messaging.DLQProducer,
+	retryCfg messaging.RetryConfig,
+	retry *messaging.RetryRouter,
+	msg kafka.Message,
+	evt event,
+) bool {
 	// ── Bounded retry loop for DB insert ──────────────────────
 	var lastErr error
 	for attempt := 0; ; attempt++ {
 		dbCtx, dbCancel := context.WithTimeout(ctx, 5*time.Second)
-		lastErr = db.InsertEvent(dbCtx, evt.EventID, evt.EventType, evt.Payload)
+		lastErr = db.InsertEvent(dbCtx, evt.row())
 		dbCancel()
 
 		if lastErr == nil {
-			// Success — commit offset
-			if err := consumer.CommitMessage(ctx, msg); err != nil {
-				logger.Error("offset commit failed (event already in DB)", map[string]any{
-					"event_id": evt.EventID,
-					"error":    err.Error(),
-				})
-			}
+			stats.persisted.Add(1)
 			logger.Info("event persisted", map[string]any{
 				"event_id":   evt.EventID,
 				"event_type": evt.EventType,
 				"offset":     msg.Offset,
 				"attempts":   attempt + 1,
 			})
-			return
+			return true
+		}
+
+		// A failure caused by the drain deadline says nothing about the
+		// event; leave it uncommitted rather than dead-letter it.
+		if ctx.Err() != nil {
+			logger.Info("insert abandoned at drain deadline", map[string]any{
+				"event_id": evt.EventID,
+			})
+			return false
 		}
 
 		kind := messaging.Classify(lastErr)
@@ -158,8 +437,34 @@ func processMessage(
 		// Permanent error — no point retrying
 		if kind == messaging.ErrPermanent {
 			sendToDLQ(ctx, logger, dlq, msg, lastErr, kind, attempt+1)
-			commitAndLog(ctx, logger, consumer, msg, "permanent-error")
-			return
+			return true
+		}
+
+		// Transient — hand off to the next retry tier if there is one
+		if retry != nil {
+			routed, err := retry.Route(ctx, msg, lastErr)
+			if routed {
+				stats.retried.Add(1)
+				metrics.Retries.WithLabelValues(kind.String()).Inc()
+				logger.Info("message routed to retry tier", map[string]any{
+					"event_id": evt.EventID,
+					"attempt":  messaging.RetryAttempt(msg) + 1,
+				})
+				return true
+			}
+			if err == nil {
+				logger.Error("retry tiers exhausted, routing to DLQ", map[string]any{
+					"event_id": evt.EventID,
+					"tiers":    len(retry.Tiers()),
+				})
+				sendToDLQ(ctx, logger, dlq, msg, lastErr, kind, messaging.RetryAttempt(msg)+1)
+				return true
+			}
+			// Retry topic unreachable — fall back to retrying in place.
+			logger.Warn("retry topic publish failed", map[string]any{
+				"event_id": evt.EventID,
+				"error":    err.Error(),
+			})
 		}
 
 		// Transient but budget exhausted
@@ -169,17 +474,17 @@ func processMessage(
 				"retries":  attempt + 1,
 			})
 			sendToDLQ(ctx, logger, dlq, msg, lastErr, kind, attempt+1)
-			commitAndLog(ctx, logger, consumer, msg, "retries-exhausted")
-			return
+			return true
 		}
 
 		// Back off before next attempt
+		metrics.Retries.WithLabelValues(kind.String()).Inc()
 		if err := retryCfg.Sleep(ctx, attempt); err != nil {
 			// Context cancelled during sleep — exit without commit
 			logger.Info("retry sleep interrupted by shutdown", map[string]any{
 				"event_id": evt.EventID,
 			})
-			return
+			return false
 		}
 	}
 }
@@ -201,6 +506,8 @@ func sendToDLQ(
 			"offset":         msg.Offset,
 		})
 	} else {
+		stats.deadLettered.Add(1)
+		metrics.DLQRoutes.WithLabelValues(kind.String()).Inc()
 		logger.Info("message routed to DLQ", map[string]any{
 			"offset":     msg.Offset,
 			"error_kind": kind.String(),
@@ -209,7 +516,7 @@ func sendToDLQ(
 	}
 }
 
-// commitAndLog commits the offset so the consumer moves past the failed message.
+// commitAndLog commits the offset so the consumer moves past the message.
 func commitAndLog(
 	ctx context.Context,
 	logger *logging.Logger,
@@ -217,8 +524,10 @@ func commitAndLog(
 	msg kafka.Message,
 	reason string,
 ) {
-	if err := consumer.CommitMessage(ctx, msg); err != nil {
-		logger.Error("offset commit failed after DLQ routing", map[string]any{
+	commitCtx, cancel := commitContext(ctx)
+	defer cancel()
+	if err := consumer.CommitMessage(commitCtx, msg); err != nil {
+		logger.Error("offset commit failed", map[string]any{
 			"error":  err.Error(),
 			"reason": reason,
 			"offset": msg.Offset,
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/messaging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+)
+
+// runRetryTier consumes one retry topic until fetchCtx is cancelled. Each
+// message waits for its not-before time and then gets one more insert
+// attempt; a transient failure moves it on to the next tier, or to the
+// DLQ after the last one. Every message in a tier has the same delay, so
+// waiting on the oldest one never holds back one that is already due.
+func runRetryTier(
+	fetchCtx, workCtx context.Context,
+	logger *logging.Logger,
+	db *storage.DB,
+	dlq *messaging.DLQProducer,
+	retryCfg messaging.RetryConfig,
+	retry *messaging.RetryRouter,
+	brokers []string,
+	groupID string,
+	tier messaging.RetryTier,
+) {
+	consumer := messaging.NewConsumer(brokers, tier.Topic, groupID+"."+tier.Topic)
+	defer consumer.Close()
+	logger.Info("retry tier consumer started", map[string]any{
+		"topic": tier.Topic,
+		"delay": tier.Delay.String(),
+	})
+
+	for {
+		msg, err := consumer.FetchMessage(fetchCtx)
+		if err != nil {
+			if fetchCtx.Err() != nil {
+				return
+			}
+			logger.Error("retry tier fetch failed", map[string]any{
+				"topic": tier.Topic,
+				"error": err.Error(),
+			})
+			continue
+		}
+
+		// A message still waiting when the drain starts is not in flight;
+		// leave it for the next consumer rather than hold up shutdown.
+		if err := messaging.WaitNotBefore(fetchCtx, msg); err != nil {
+			stats.abandoned.Add(1)
+			return
+		}
+		processMessage(workCtx, logger, db, consumer, dlq, retryCfg, retry, msg)
+	}
+}
@@ -1,29 +1,282 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/handlers"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/middleware"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/auth"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/config"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/health"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/messaging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/quota"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/ratelimit"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/schema"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/spool"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tracing"
 )
 
 func main() {
 	cfg := config.Load()
-	logger := logging.New(cfg.ServiceName)
+	logger := logging.New(cfg.ServiceName, cfg.LogLevel)
 
 	logger.Info("starting service", map[string]any{
 		"port": cfg.Port,
 	})
 
+	mode, err := handlers.ParsePublishMode(cfg.PublishMode)
+	if err != nil {
+		logger.Error("invalid PUBLISH_MODE", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
+	if mode == handlers.PublishSpool && cfg.SpoolDir == "" {
+		logger.Error("PUBLISH_MODE=spool requires SPOOL_DIR", map[string]any{})
+		os.Exit(1)
+	}
+
+	tenantQuotas, err := quota.ParseTenantLimits(cfg.TenantQuotas)
+	if err != nil {
+		logger.Error("invalid TENANT_QUOTAS", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
+
+	if cfg.RateLimitStore != "memory" && cfg.RateLimitStore != "postgres" {
+		logger.Error("invalid RATE_LIMIT_STORE", map[string]any{"rate_limit_store": cfg.RateLimitStore})
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.ServiceName, cfg.TraceExporter)
+	if err != nil {
+		logger.Error("invalid TRACE_EXPORTER", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
+
+	// ── Connect to PostgreSQL ──────────────────────────────────
+	db, err := storage.New(cfg.DatabaseDSN)
+	if err != nil {
+		logger.Error("failed to connect to postgres", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
+	defer db.Close()
+	logger.Info("connected to postgres", map[string]any{})
+
+	// ── Open spool ─────────────────────────────────────────────
+	var sp *spool.Spool
+	if cfg.SpoolDir != "" {
+		sp, err = spool.Open(cfg.SpoolDir, spool.Options{
+			MaxBytes:     cfg.SpoolMaxBytes,
+			SegmentBytes: cfg.SpoolSegmentBytes,
+			Logger:       logger,
+		})
+		if err != nil {
+			logger.Error("failed to open spool", map[string]any{"error": err.Error(), "dir": cfg.SpoolDir})
+			os.Exit(1)
+		}
+		metrics.ObserveSpool(func() (int64, int64) {
+			st := sp.Stats()
+			return st.Records, st.Bytes
+		})
+		st := sp.Stats()
+		logger.Info("spool opened", map[string]any{
+			"dir":     cfg.SpoolDir,
+			"records": st.Records,
+			"bytes":   st.Bytes,
+		})
+	}
+
+	// ── Check Kafka ────────────────────────────────────────────
+	// Without a spool there is nowhere to put accepted events while the
+	// broker is down, so refuse to start.
+	checkCtx, checkCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	err = messaging.CheckBrokers(checkCtx, cfg.KafkaBrokers, cfg.KafkaTopic)
+	checkCancel()
+	switch {
+	case err != nil && sp == nil:
+		logger.Error("kafka unreachable", map[string]any{"error": err.Error(), "brokers": cfg.KafkaBrokers})
+		os.Exit(1)
+	case err != nil:
+		logger.Warn("kafka unreachable, events will be spooled", map[string]any{"error": err.Error(), "brokers": cfg.KafkaBrokers})
+	default:
+		logger.Info("kafka reachable", map[string]any{"brokers": cfg.KafkaBrokers, "topic": cfg.KafkaTopic})
+	}
+
+	producer := messaging.NewProducer(cfg.KafkaBrokers, cfg.KafkaTopic)
+
+	// ── Readiness checks ───────────────────────────────────────
+	health.Register("postgres", db.Ping, health.CheckOptions{})
+	health.Register("kafka", func(ctx context.Context) error {
+		return messaging.CheckBrokers(ctx, cfg.KafkaBrokers, cfg.KafkaTopic)
+	}, health.CheckOptions{Timeout: 3 * time.Second, CacheTTL: 5 * time.Second, NonCritical: sp != nil})
+	if sp != nil {
+		health.Register("spool", func(context.Context) error {
+			// Stop taking traffic before Append starts failing with ErrFull.
+			if st := sp.Stats(); st.Bytes >= cfg.SpoolMaxBytes/10*9 {
+				return fmt.Errorf("spool over 90%% full: %d records, %d bytes", st.Records, st.Bytes)
+			}
+			return nil
+		}, health.CheckOptions{})
+	}
+
+	// Async publishes outlive their request; shutdown waits for them
+	// before the producer is closed.
+	var inFlight sync.WaitGroup
+	ingest := handlers.IngestOptions{
+		Mode:              mode,
+		PublishTimeout:    cfg.PublishTimeout,
+		RetryAfter:        cfg.PublishRetryAfter,
+		MaxBatchEvents:    cfg.MaxBatchEvents,
+		StreamBatchEvents: cfg.StreamBatchEvents,
+		MaxBodyBytes:      cfg.MaxBodyBytes,
+		MaxBatchBodyBytes: cfg.MaxBatchBodyBytes,
+		MaxPayloadBytes:   cfg.MaxPayloadBytes,
+		MaxJSONDepth:      cfg.MaxJSONDepth,
+		StrictJSON:        cfg.StrictJSON,
+		Schemas:           schema.NewRegistry(db, cfg.SchemaRefreshInterval),
+		MaxFutureSkew:     cfg.EventMaxFutureSkew,
+		MaxLateness:       cfg.EventMaxLateness,
+		GenerateIDs:       cfg.GenerateEventIDs,
+		InFlight:          &inFlight,
+		Logger:            logger,
+	}
+	if sp != nil {
+		ingest.Spool = sp // a nil *Spool must not become a non-nil Spooler
+	}
+	if cfg.TenantDailyQuota > 0 || len(tenantQuotas) > 0 {
+		ingest.Quota = quota.New(db, quota.Limits{Default: cfg.TenantDailyQuota, Tenants: tenantQuotas}, cfg.QuotaRefreshInterval)
+	}
+
+	// ── Spool drainer ──────────────────────────────────────────
+	drainCtx, stopDrainer := context.WithCancel(context.Background())
+	drainerDone := make(chan struct{})
+	if sp != nil {
+		go func() {
+			defer close(drainerDone)
+			sp.Run(drainCtx, cfg.SpoolDrainInterval, func(ctx context.Context, records []spool.Record) (int, error) {
+				batch := make([]messaging.KeyedEvent, len(records))
+				for i, rec := range records {
+					batch[i] = messaging.KeyedEvent{Key: rec.Key, Event: json.RawMessage(rec.Value)}
+				}
+				err := producer.PublishBatch(ctx, batch)
+				return messaging.Written(len(batch), err), err
+			}, logger)
+		}()
+	} else {
+		close(drainerDone)
+	}
+
+	// ── Authentication ─────────────────────────────────────────
+	routerOpts := api.RouterOptions{CORSOrigins: cfg.CORSOrigins}
+	if cfg.AuthEnabled {
+		routerOpts.Auth = auth.NewAuthenticator(db, cfg.AuthCacheTTL, cfg.AdminAPIKey)
+	} else {
+		logger.Warn("AUTH_ENABLED is off: /v1 is open and every request acts for the default tenant", map[string]any{})
+	}
+
+	// ── Rate limiting ──────────────────────────────────────────
+	routerOpts.RateLimits = middleware.RateLimits{
+		IP:                ratelimit.Limit{Rate: cfg.RateLimitIPRPS, Burst: cfg.RateLimitIPBurst},
+		Key:               ratelimit.Limit{Rate: cfg.RateLimitKeyRPS, Burst: cfg.RateLimitKeyBurst},
+		Tenant:            ratelimit.Limit{Rate: cfg.RateLimitTenantRPS, Burst: cfg.RateLimitTenantBurst},
+		TrustForwardedFor: cfg.TrustForwardedFor,
+	}
+	if cfg.RateLimitIPRPS > 0 || cfg.RateLimitKeyRPS > 0 || cfg.RateLimitTenantRPS > 0 {
+		if cfg.RateLimitStore == "postgres" {
+			routerOpts.RateLimiter = ratelimit.NewShared(db)
+		} else {
+			routerOpts.RateLimiter = ratelimit.NewMemory()
+		}
+	}
+
 	server := &http.Server{
 		Addr:    ":" + cfg.Port,
-		Handler: api.NewRouter(),
+		Handler: api.NewRouter(logger, producer, db, ingest, routerOpts),
 	}
 
-	if err := server.ListenAndServe(); err != nil {
+	// ── Graceful shutdown ──────────────────────────────────────
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	logger.Info("listening", map[string]any{"addr": server.Addr, "publish_mode": string(mode)})
+
+	select {
+	case err := <-serveErr:
 		logger.Error("server stopped", map[string]any{
 			"error": err.Error(),
 		})
+		stopDrainer()
+		<-drainerDone
+		producer.Close()
+		return
+	case <-ctx.Done():
+	}
+
+	// 1. Report not-ready so load balancers stop sending new requests.
+	logger.Info("shutdown signal received, draining", map[string]any{
+		"ready_delay": cfg.ShutdownReadyDelay.String(),
+		"timeout":     cfg.ShutdownTimeout.String(),
+	})
+	health.StartDraining()
+	time.Sleep(cfg.ShutdownReadyDelay)
+
+	// 2. Stop accepting connections and wait for in-flight requests.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("http shutdown incomplete", map[string]any{"error": err.Error()})
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("server stopped", map[string]any{"error": err.Error()})
+	}
+
+	// 3. Wait for detached publishes, then stop the drainer and flush the
+	// producer. Events still spooled stay on disk for the next start.
+	if err := waitGroup(shutdownCtx, &inFlight); err != nil {
+		logger.Error("in-flight publishes did not finish before the deadline", map[string]any{"error": err.Error()})
+	}
+	stopDrainer()
+	<-drainerDone
+	if sp != nil {
+		if err := sp.Close(); err != nil {
+			logger.Error("spool close failed", map[string]any{"error": err.Error()})
+		}
+	}
+	if err := producer.Close(); err != nil {
+		logger.Error("producer flush failed", map[string]any{"error": err.Error()})
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("trace flush failed", map[string]any{"error": err.Error()})
+	}
+	logger.Info("service stopped", map[string]any{})
+}
+
+// waitGroup waits for wg or until ctx ends.
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
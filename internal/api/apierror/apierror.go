@@ -0,0 +1,109 @@
+// Package apierror writes the API's error responses. Every error, from a
+// handler or a middleware, has the same JSON body:
+//
+//	{"error": {"code": "not_found", "message": "event not found", "request_id": "…", "details": …}}
+//
+// Code is stable and meant for programs; Message is for people and may
+// change. Details is present only for codes that document it.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
+)
+
+// Codes shared by every endpoint.
+const (
+	CodeInvalidJSON      = "invalid_json"
+	CodeInvalidParameter = "invalid_parameter" // a path or query parameter
+	CodeInvalidField     = "invalid_field"     // a body field; details name it
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeRateLimited      = "rate_limited"
+	CodeAuthUnavailable  = "auth_unavailable"
+	CodeInternal         = "internal_error"
+)
+
+// Codes returned by the ingestion endpoints, for whole requests and for
+// individual batch and stream items.
+const (
+	CodeTrailingData      = "trailing_data"
+	CodeUnknownField      = "unknown_field"
+	CodeJSONTooDeep       = "json_too_deep"
+	CodeBodyTooLarge      = "body_too_large"
+	CodeEventTooLarge     = "event_too_large"
+	CodePayloadTooLarge   = "payload_too_large"
+	CodeInvalidEventID    = "invalid_event_id"
+	CodeInvalidOccurredAt = "invalid_occurred_at"
+	CodeSchemaMismatch    = "schema_mismatch"
+	CodeEmptyBatch        = "empty_batch"
+	CodeBatchTooLarge     = "batch_too_large"
+	CodeSchemaUnavailable = "schema_registry_unavailable"
+	CodeBrokerUnavailable = "broker_unavailable"
+	CodeSpoolUnavailable  = "spool_unavailable"
+	CodeQuotaExceeded     = "quota_exceeded"
+)
+
+// Body is the JSON body of an error response.
+type Body struct {
+	Error Detail `json:"error"`
+}
+
+// Detail describes why a request failed.
+type Detail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+// FieldDetails are the details of a CodeInvalidField error.
+type FieldDetails struct {
+	Field string `json:"field"`
+}
+
+// Error is a failure to report to the client with its status and code.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details any
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New returns an Error without details.
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Write writes an error response for the request r.
+func Write(w http.ResponseWriter, r *http.Request, status int, code, message string, details any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Body{Error: Detail{
+		Code:      code,
+		Message:   message,
+		RequestID: logging.RequestID(r.Context()),
+		Details:   details,
+	}})
+}
+
+// NotFound responds to requests for routes that do not exist.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, http.StatusNotFound, CodeNotFound, "no route for "+r.URL.Path, nil)
+}
+
+// MethodNotAllowed responds to requests whose route exists but not for
+// their method.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path, nil)
+}
@@ -0,0 +1,52 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
+)
+
+func newRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/v1/events/x", nil)
+	return r.WithContext(logging.ContextWithRequestID(r.Context(), "req-1"))
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) Detail {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected a JSON content type, got %q", ct)
+	}
+	var body Body
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body is not an error envelope: %q: %v", w.Body.String(), err)
+	}
+	return body.Error
+}
+
+func TestWrite_Envelope(t *testing.T) {
+	w := httptest.NewRecorder()
+	Write(w, newRequest(), http.StatusBadRequest, CodeInvalidField, "name is required", FieldDetails{Field: "name"})
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	d := decode(t, w)
+	if d.Code != CodeInvalidField || d.Message != "name is required" || d.RequestID != "req-1" {
+		t.Errorf("unexpected error %+v", d)
+	}
+	if details, _ := d.Details.(map[string]any); details["field"] != "name" {
+		t.Errorf("expected details to name the field, got %v", d.Details)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	w := httptest.NewRecorder()
+	NotFound(w, newRequest())
+
+	if d := decode(t, w); w.Code != http.StatusNotFound || d.Code != CodeNotFound {
+		t.Errorf("expected a 404 not_found, got %d %+v", w.Code, d)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/auth"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+// KeyHandlers manage API keys under /v1/admin/keys. A tenant's admins
+// manage that tenant's keys; operators manage every tenant's.
+type KeyHandlers struct {
+	DB *storage.DB
+}
+
+// CreateKeyRequest is the body of POST /v1/admin/keys.
+type CreateKeyRequest struct {
+	// TenantID defaults to the caller's tenant. Only operators may name
+	// another.
+	TenantID string   `json:"tenant_id"`
+	Name     string   `json:"name"`
+	Scopes   []string `json:"scopes"`
+}
+
+// CreateKeyResponse returns the stored key together with the plaintext,
+// which is not retrievable afterwards.
+type CreateKeyResponse struct {
+	storage.APIKey
+	Key string `json:"key"`
+}
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// CreateKey handles POST /v1/admin/keys.
+func (h *KeyHandlers) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidJSON, "invalid json", nil)
+		return
+	}
+	own, _ := tenant.From(r.Context())
+	if req.TenantID == "" {
+		req.TenantID = own
+	}
+	if !tenantIDPattern.MatchString(req.TenantID) {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidField, "tenant_id must be 1-64 lowercase letters, digits, '-' or '_'", apierror.FieldDetails{Field: "tenant_id"})
+		return
+	}
+	if _, operator := keyContext(r); req.TenantID != own && !operator {
+		apierror.Write(w, r, http.StatusForbidden, apierror.CodeForbidden, "api keys can only be created for your own tenant", nil)
+		return
+	}
+	if req.Name == "" {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidField, "name is required", apierror.FieldDetails{Field: "name"})
+		return
+	}
+	if len(req.Scopes) == 0 {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidField, "scopes is required", apierror.FieldDetails{Field: "scopes"})
+		return
+	}
+	for _, s := range req.Scopes {
+		if !auth.ValidScope(s) {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidField, "unknown scope "+strconv.Quote(s), apierror.FieldDetails{Field: "scopes"})
+			return
+		}
+	}
+
+	key, hash, prefix, err := auth.Generate()
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to generate key", nil)
+		return
+	}
+	created, err := h.DB.CreateAPIKey(r.Context(), storage.APIKey{
+		TenantID: req.TenantID,
+		Name:     req.Name,
+		Prefix:   prefix,
+		Scopes:   req.Scopes,
+	}, hash)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to create key", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateKeyResponse{APIKey: *created, Key: key})
+}
+
+// ListKeys handles GET /v1/admin/keys. Operators may pass ?tenant_id=acme
+// to list one tenant; everyone else sees their own tenant's keys.
+func (h *KeyHandlers) ListKeys(w http.ResponseWriter, r *http.Request) {
+	ctx, operator := keyContext(r)
+	filter := r.URL.Query().Get("tenant_id")
+	if own, _ := tenant.From(r.Context()); filter != "" && filter != own && !operator {
+		apierror.Write(w, r, http.StatusForbidden, apierror.CodeForbidden, "api keys of other tenants are not visible", nil)
+		return
+	}
+	keys, err := h.DB.ListAPIKeys(ctx, filter)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to list keys", nil)
+		return
+	}
+	if keys == nil {
+		keys = []storage.APIKey{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// RevokeKey handles DELETE /v1/admin/keys/{id}. Keys of other tenants are
+// not found unless the caller is an operator. Cached lookups of the key
+// stay valid until they expire.
+func (h *KeyHandlers) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, "invalid id", nil)
+		return
+	}
+	ctx, _ := keyContext(r)
+	revoked, err := h.DB.RevokeAPIKey(ctx, id)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to revoke key", nil)
+		return
+	}
+	if !revoked {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "key not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// keyContext returns the context key storage calls run with and whether
+// the caller is an operator. Operators see every tenant's keys; anyone
+// else only those of the tenant the request context is scoped to.
+func keyContext(r *http.Request) (context.Context, bool) {
+	if k, ok := auth.FromContext(r.Context()); ok && k.Has(auth.ScopeOperator) {
+		return tenant.WithAll(r.Context()), true
+	}
+	return r.Context(), false
+}
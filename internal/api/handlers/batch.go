@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/messaging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/schema"
+)
+
+// BatchRequest is the body of POST /v1/events/batch. Items are kept raw
+// so one malformed event does not fail the whole batch.
+type BatchRequest struct {
+	Events []json.RawMessage `json:"events"`
+}
+
+// Batch item statuses.
+const (
+	ItemAccepted = "accepted"
+	ItemInvalid  = "invalid"
+	ItemFailed   = "failed"
+)
+
+// BatchItemResult reports the outcome for one event, by position.
+type BatchItemResult struct {
+	Index   int    `json:"index"`
+	EventID string `json:"event_id,omitempty"`
+	Status  string `json:"status"`
+	Code    string `json:"code,omitempty"` // an apierror code
+	Reason  string `json:"reason,omitempty"`
+	// Fields lists schema violations when Status is "invalid".
+	Fields []schema.FieldError `json:"fields,omitempty"`
+}
+
+// HandleEventBatch handles POST /v1/events/batch.
+//
+// Every item is validated on its own; valid items are published with a
+// single WriteMessages call and the handler waits for the broker ack so
+// each item gets an accurate status. Items Kafka rejects fall back to the
+// spool when one is configured.
+//
+// Responds 202 when at least one item was accepted, 400 when every item
+// is invalid, 429 with Retry-After when the valid items would exceed a
+// rate limit (one token per item) or the tenant's daily quota, and 503
+// with Retry-After when no valid item was accepted.
+func HandleEventBatch(producer *messaging.Producer, opts IngestOptions) http.HandlerFunc {
+	opts = opts.withDefaults()
+	retryAfter := opts.retryAfterSeconds()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body BatchRequest
+		// Events sit two levels down, inside the body object and its array.
+		if err := decodeBody(w, r, opts.MaxBatchBodyBytes, opts.MaxJSONDepth+2, opts.StrictJSON, &body); err != nil {
+			writeEventError(w, r, err, retryAfter)
+			return
+		}
+		if len(body.Events) == 0 {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeEmptyBatch, "events must not be empty", nil)
+			return
+		}
+		if len(body.Events) > opts.MaxBatchEvents {
+			apierror.Write(w, r, http.StatusRequestEntityTooLarge, apierror.CodeBatchTooLarge, fmt.Sprintf("batch exceeds %d events", opts.MaxBatchEvents), nil)
+			return
+		}
+
+		results := make([]BatchItemResult, len(body.Events))
+		var reqs []EventRequest
+		var positions []int // positions[i] is the batch index of reqs[i]
+
+		for i, raw := range body.Events {
+			results[i] = BatchItemResult{Index: i}
+
+			var req EventRequest
+			if err := decodeJSON(raw, opts.MaxJSONDepth, opts.StrictJSON, &req); err != nil {
+				results[i] = rejectedItem(i, "", err)
+				continue
+			}
+			results[i].EventID = req.EventID
+			if err := opts.validateEvent(r.Context(), &req); err != nil {
+				results[i] = rejectedItem(i, req.EventID, err)
+				continue
+			}
+			results[i].EventID = req.EventID // may have been generated
+			reqs = append(reqs, req)
+			positions = append(positions, i)
+		}
+
+		if len(reqs) > 0 {
+			// The rate limit middleware charged the request's first event.
+			if rateRetryAfter, ok := opts.takeRateLimit(r, len(reqs)-1); !ok {
+				countEvents("batch", 0, len(results)-len(reqs), 0)
+				w.Header().Set("Retry-After", rateRetryAfter)
+				apierror.Write(w, r, http.StatusTooManyRequests, apierror.CodeRateLimited, errRateLimited.Error(), nil)
+				return
+			}
+			// Either every valid item fits in the quota or none is published.
+			if quotaRetryAfter, ok := opts.chargeQuota(r.Context(), reqs[0].TenantID, len(reqs)); !ok {
+				countEvents("batch", 0, len(results)-len(reqs), 0)
+				rejectOverQuota(w, r, "batch", len(reqs), quotaRetryAfter)
+				return
+			}
+
+			errs := publishBatch(r.Context(), producer, opts, reqs)
+			failCode, failReason := opts.publishFailure()
+			failed := 0
+			for j, err := range errs {
+				i := positions[j]
+				if err != nil {
+					results[i].Status, results[i].Code, results[i].Reason = ItemFailed, failCode, failReason
+					failed++
+					continue
+				}
+				results[i].Status = ItemAccepted
+			}
+			opts.refundQuota(reqs[0].TenantID, failed)
+		}
+
+		counts := map[string]int{}
+		for _, res := range results {
+			counts[res.Status]++
+		}
+		countEvents("batch", counts[ItemAccepted], counts[ItemInvalid], counts[ItemFailed])
+
+		status := http.StatusAccepted
+		switch {
+		case counts[ItemInvalid] == len(results):
+			status = http.StatusBadRequest
+		case counts[ItemAccepted] == 0:
+			status = http.StatusServiceUnavailable
+			w.Header().Set("Retry-After", retryAfter)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"accepted": counts[ItemAccepted],
+			"invalid":  counts[ItemInvalid],
+			"failed":   counts[ItemFailed],
+			"results":  results,
+		})
+	}
+}
+
+// publishBatch publishes reqs in one call and returns a per-item error,
+// after attempting the spool fallback for items Kafka did not accept. In
+// PublishSpool mode it only appends them to the spool.
+func publishBatch(ctx context.Context, producer *messaging.Producer, opts IngestOptions, reqs []EventRequest) []error {
+	if opts.Mode == PublishSpool {
+		errs := make([]error, len(reqs))
+		for i, req := range reqs {
+			errs[i] = spoolEvent(opts.Spool, req)
+		}
+		return errs
+	}
+
+	batch := make([]messaging.KeyedEvent, len(reqs))
+	for i, req := range reqs {
+		batch[i] = messaging.KeyedEvent{Key: req.key(), Event: req}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.PublishTimeout)
+	err := producer.PublishBatch(ctx, batch)
+	cancel()
+
+	errs := make([]error, len(reqs))
+	var batchErr *messaging.BatchError
+	switch {
+	case err == nil:
+		return errs
+	case errors.As(err, &batchErr):
+		copy(errs, batchErr.Errs)
+	default:
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+
+	if opts.Spool != nil && opts.Mode != PublishSync {
+		for i, req := range reqs {
+			if errs[i] != nil {
+				errs[i] = spoolEvent(opts.Spool, req)
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+)
+
+type batchResponse struct {
+	Accepted int               `json:"accepted"`
+	Invalid  int               `json:"invalid"`
+	Failed   int               `json:"failed"`
+	Results  []BatchItemResult `json:"results"`
+}
+
+// postBatch sends events to HandleEventBatch in spool mode.
+func postBatch(t *testing.T, spool *memSpool, maxEvents int, events ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	h := HandleEventBatch(nil, IngestOptions{Mode: PublishSpool, Spool: spool, MaxBatchEvents: maxEvents})
+	body := `{"events":[` + strings.Join(events, ",") + `]}`
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest(http.MethodPost, "/v1/events/batch", strings.NewReader(body)))
+	return w
+}
+
+func decodeBatch(t *testing.T, w *httptest.ResponseRecorder) batchResponse {
+	t.Helper()
+	var res batchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("unexpected body %q: %v", w.Body.String(), err)
+	}
+	return res
+}
+
+func TestHandleEventBatch_PerItemStatus(t *testing.T) {
+	spool := &memSpool{}
+	w := postBatch(t, spool, 0,
+		eventJSON(0),
+		`{"event_id":"not-a-uuid","event_type":"click","payload":{}}`,
+		eventJSON(2),
+	)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	res := decodeBatch(t, w)
+	if res.Accepted != 2 || res.Invalid != 1 || res.Failed != 0 || len(spool.values) != 2 {
+		t.Errorf("expected 2 accepted and spooled, 1 invalid, got %+v and %d spooled", res, len(spool.values))
+	}
+	want := []struct{ status, code string }{
+		{ItemAccepted, ""},
+		{ItemInvalid, apierror.CodeInvalidEventID},
+		{ItemAccepted, ""},
+	}
+	for i, item := range res.Results {
+		if item.Index != i || item.Status != want[i].status || item.Code != want[i].code {
+			t.Errorf("item %d: expected %s %q, got %+v", i, want[i].status, want[i].code, item)
+		}
+	}
+}
+
+func TestHandleEventBatch_AllInvalid(t *testing.T) {
+	w := postBatch(t, &memSpool{}, 0, `{"event_id":"x"}`, `[]`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if res := decodeBatch(t, w); res.Invalid != 2 || res.Accepted != 0 {
+		t.Errorf("expected both items invalid, got %+v", res)
+	}
+}
+
+func TestHandleEventBatch_NoneAccepted(t *testing.T) {
+	w := postBatch(t, &memSpool{err: errors.New("disk full")}, 0, eventJSON(0), `{"event_id":"x"}`)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After on 503")
+	}
+	res := decodeBatch(t, w)
+	if res.Failed != 1 || res.Invalid != 1 {
+		t.Errorf("expected 1 failed and 1 invalid, got %+v", res)
+	}
+	if item := res.Results[0]; item.Status != ItemFailed || item.Code != apierror.CodeSpoolUnavailable {
+		t.Errorf("expected the valid item to fail with spool_unavailable, got %+v", item)
+	}
+}
+
+func TestHandleEventBatch_MaxBatchEvents(t *testing.T) {
+	spool := &memSpool{}
+	if w := postBatch(t, spool, 2, eventJSON(0), eventJSON(1)); w.Code != http.StatusAccepted {
+		t.Fatalf("expected a batch at the cap to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := postBatch(t, spool, 2, eventJSON(0), eventJSON(1), eventJSON(2))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), apierror.CodeBatchTooLarge) {
+		t.Errorf("expected code %s, got %s", apierror.CodeBatchTooLarge, w.Body.String())
+	}
+	if len(spool.values) != 2 {
+		t.Errorf("expected nothing of the oversized batch to be spooled, got %d events", len(spool.values))
+	}
+}
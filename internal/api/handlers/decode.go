@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+)
+
+// decodeBody reads at most limit bytes of r's body and decodes the single
+// JSON document in it into v. Documents nested deeper than maxDepth,
+// trailing data and, when strict, fields v does not define are rejected.
+// Errors are *apierror.Error.
+func decodeBody(w http.ResponseWriter, r *http.Request, limit int64, maxDepth int, strict bool, v any) error {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return &apierror.Error{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    apierror.CodeBodyTooLarge,
+			Message: fmt.Sprintf("request body exceeds %d bytes", limit),
+		}
+	}
+	if err != nil {
+		return badRequest(apierror.CodeInvalidJSON, "failed to read request body")
+	}
+	return decodeJSON(data, maxDepth, strict, v)
+}
+
+// decodeJSON decodes the single JSON document in data into v with the
+// checks described on decodeBody.
+func decodeJSON(data []byte, maxDepth int, strict bool, v any) error {
+	if err := checkDepth(data, maxDepth); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return badRequest(apierror.CodeUnknownField, "unknown field "+field)
+		}
+		return badRequest(apierror.CodeInvalidJSON, "invalid json: "+err.Error())
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return badRequest(apierror.CodeTrailingData, "unexpected data after the JSON document")
+	}
+	return nil
+}
+
+// checkDepth rejects documents whose objects and arrays nest deeper than
+// max, before the decoder recurses into them. It does not validate
+// syntax; malformed documents fail to decode afterwards.
+func checkDepth(data []byte, max int) error {
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+			if depth > max {
+				return badRequest(apierror.CodeJSONTooDeep, fmt.Sprintf("JSON nests deeper than %d levels", max))
+			}
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// errEventTooLarge stops a stream whose current event exceeds the per
+// event budget.
+var errEventTooLarge = errors.New("event too large")
+
+// budgetReader fails once more than its budget has been read. The stream
+// handler renews the budget before each event, so a single oversized
+// event cannot make the decoder buffer without bound.
+type budgetReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (b *budgetReader) Read(p []byte) (int, error) {
+	if b.left <= 0 {
+		return 0, errEventTooLarge
+	}
+	if int64(len(p)) > b.left {
+		p = p[:b.left]
+	}
+	n, err := b.r.Read(p)
+	b.left -= int64(n)
+	return n, err
+}
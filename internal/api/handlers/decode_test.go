@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type event struct {
+		Name    string          `json:"name"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	tests := []struct {
+		name     string
+		data     string
+		maxDepth int
+		strict   bool
+		code     string // "" expects success
+	}{
+		{"depth at the limit", `{"payload":{"a":[1]}}`, 3, false, ""},
+		{"depth over the limit", `{"payload":{"a":[[1]]}}`, 3, false, apierror.CodeJSONTooDeep},
+		{"brackets inside a string", `{"name":"[[[{{{","payload":{}}`, 2, false, ""},
+		{"escaped quote inside a string", `{"name":"say \"[[[\" {{","payload":{}}`, 2, false, ""},
+		{"escaped backslash ends the string", `{"name":"a\\","payload":{"a":[[1]]}}`, 3, false, apierror.CodeJSONTooDeep},
+		{"trailing document", `{"name":"a"} {"name":"b"}`, 32, false, apierror.CodeTrailingData},
+		{"trailing garbage", `{"name":"a"}x`, 32, false, apierror.CodeTrailingData},
+		{"trailing whitespace", "{\"name\":\"a\"}\n\t ", 32, false, ""},
+		{"unknown field ignored", `{"name":"a","extra":1}`, 32, false, ""},
+		{"unknown field with StrictJSON", `{"name":"a","extra":1}`, 32, true, apierror.CodeUnknownField},
+		{"syntax error", `{"name":}`, 32, false, apierror.CodeInvalidJSON},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var v event
+			err := decodeJSON([]byte(tc.data), tc.maxDepth, tc.strict, &v)
+			if tc.code == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var apiErr *apierror.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an *apierror.Error with code %s, got %v", tc.code, err)
+			}
+			if apiErr.Code != tc.code {
+				t.Errorf("expected code %s, got %s (%s)", tc.code, apiErr.Code, apiErr.Message)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_TooLarge(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/events", strings.NewReader(`{"name":"abcdefghij"}`))
+	var v map[string]any
+	err := decodeBody(httptest.NewRecorder(), r, 10, 32, false, &v)
+
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusRequestEntityTooLarge || apiErr.Code != apierror.CodeBodyTooLarge {
+		t.Errorf("expected 413 body_too_large, got %v", err)
+	}
+}
+
+// memSpool keeps spooled events in memory, or fails every append with
+// err.
+type memSpool struct {
+	mu     sync.Mutex
+	values [][]byte
+	err    error
+}
+
+func (s *memSpool) Append(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.values = append(s.values, value)
+	return nil
+}
+
+// eventJSON returns a valid event whose event_id ends in i.
+func eventJSON(i int) string {
+	return fmt.Sprintf(`{"event_id":"0190a0d4-7a5c-7000-8000-%012d","event_type":"click","payload":{}}`, i)
+}
+
+func TestHandleEventStream_EventTooLarge(t *testing.T) {
+	huge := `{"event_id":"0190a0d4-7a5c-7000-8000-000000000099","event_type":"click","payload":{"pad":"` + strings.Repeat("x", 4096) + `"}}`
+	tests := []struct {
+		name       string
+		events     []string
+		resumeFrom int
+		accepted   int
+	}{
+		{"first event", []string{huge, eventJSON(1)}, 0, 0},
+		{"after a flushed batch", []string{eventJSON(0), eventJSON(1), huge, eventJSON(3)}, 2, 2},
+		{"after a pending batch", []string{eventJSON(0), eventJSON(1), eventJSON(2), huge}, 3, 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			spool := &memSpool{}
+			h := HandleEventStream(nil, IngestOptions{
+				Mode:              PublishSpool,
+				Spool:             spool,
+				StreamBatchEvents: 2,
+				MaxBodyBytes:      1024,
+			})
+			body := strings.Join(tc.events, "\n") + "\n"
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest(http.MethodPost, "/v1/events/stream", strings.NewReader(body)))
+
+			lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+			var summary StreamProgress
+			if err := json.Unmarshal([]byte(lines[len(lines)-1]), &summary); err != nil {
+				t.Fatalf("last line is not a summary: %q: %v", lines[len(lines)-1], err)
+			}
+			if summary.Type != "summary" || summary.Code != apierror.CodeEventTooLarge {
+				t.Fatalf("expected an event_too_large summary, got %+v", summary)
+			}
+			if summary.ResumeFrom == nil || *summary.ResumeFrom != tc.resumeFrom {
+				t.Errorf("expected resume_from %d, got %v", tc.resumeFrom, summary.ResumeFrom)
+			}
+			if summary.Accepted != tc.accepted || len(spool.values) != tc.accepted {
+				t.Errorf("expected %d events accepted and spooled, got %d and %d", tc.accepted, summary.Accepted, len(spool.values))
+			}
+		})
+	}
+}
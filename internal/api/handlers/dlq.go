@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListDLQ handles GET /v1/dlq with optional query params:
+//
+//	?error_kind=permanent&topic=events&type=click&replayed=false&from=...&to=...&limit=50&offset=0
+func (q *QueryHandlers) ListDLQ(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	filter := storage.DLQFilter{
+		ErrorKind:     query.Get("error_kind"),
+		OriginalTopic: query.Get("topic"),
+		EventType:     query.Get("type"),
+	}
+	if v := query.Get("replayed"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, "replayed must be true or false", nil)
+			return
+		}
+		filter.Replayed = &b
+	}
+	var ok bool
+	if filter.From, ok = queryTime(w, r, "from"); !ok {
+		return
+	}
+	if filter.To, ok = queryTime(w, r, "to"); !ok {
+		return
+	}
+
+	events, total, err := q.DB.GetDLQEvents(r.Context(), filter, limit, offset)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to query dlq events", nil)
+		return
+	}
+	if events == nil {
+		events = []storage.DLQEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events": events,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// queryTime parses the RFC 3339 query parameter name, nil if absent. A
+// malformed value gets 400 and false.
+func queryTime(w http.ResponseWriter, r *http.Request, name string) (*time.Time, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, name+" must be an RFC 3339 timestamp", nil)
+		return nil, false
+	}
+	return &t, true
+}
+
+// GetDLQ handles GET /v1/dlq/{id}
+func (q *QueryHandlers) GetDLQ(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, "invalid dlq id", nil)
+		return
+	}
+
+	event, err := q.DB.GetDLQEvent(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to get dlq event", nil)
+		return
+	}
+	if event == nil {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "dlq event not found", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(event)
+}
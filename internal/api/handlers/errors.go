@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/schema"
+)
+
+func badRequest(code, message string) *apierror.Error {
+	return apierror.New(http.StatusBadRequest, code, message)
+}
+
+// schemaMismatchDetails are the details of an apierror.CodeSchemaMismatch
+// error.
+type schemaMismatchDetails struct {
+	SchemaVersion int                 `json:"schema_version"`
+	Fields        []schema.FieldError `json:"fields"`
+}
+
+// errorCode classifies an error returned while decoding or validating an
+// event into its status, code and details.
+func errorCode(err error) (status int, code string, details any) {
+	var ae *apierror.Error
+	var ve *schema.ValidationError
+	switch {
+	case errors.As(err, &ae):
+		return ae.Status, ae.Code, ae.Details
+	case errors.As(err, &ve):
+		return http.StatusBadRequest, apierror.CodeSchemaMismatch, schemaMismatchDetails{SchemaVersion: ve.Version, Fields: ve.Fields}
+	case errors.Is(err, errSchemaUnavailable):
+		return http.StatusServiceUnavailable, apierror.CodeSchemaUnavailable, nil
+	}
+	return http.StatusInternalServerError, apierror.CodeInternal, nil
+}
@@ -3,10 +3,21 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/messaging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/quota"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/schema"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tenant"
 	"github.com/google/uuid"
 )
 
@@ -14,34 +25,426 @@ type EventRequest struct {
 	EventID string          `json:"event_id"`
 	Type    string          `json:"event_type"`
 	Payload json.RawMessage `json:"payload"`
+	// OccurredAt is when the event happened on the client. Optional;
+	// analytics fall back to the time the event was stored.
+	OccurredAt *time.Time `json:"occurred_at,omitempty"`
+	// SchemaVersion is set by the API to the schema the payload was
+	// validated against; client-supplied values are discarded.
+	SchemaVersion int `json:"schema_version,omitempty"`
+	// TenantID is set by the API to the tenant of the caller's API key;
+	// client-supplied values are discarded.
+	TenantID string `json:"tenant_id,omitempty"`
 }
 
-func HandleEvent(producer *messaging.Producer) http.HandlerFunc {
+// PublishMode controls what a 202 from POST /v1/events guarantees.
+type PublishMode string
+
+const (
+	// PublishAsync returns 202 immediately and publishes in the background.
+	// Publish failures are invisible to the caller; they fall back to the
+	// spool when one is configured, and are logged and counted as failed
+	// when that fails too.
+	PublishAsync PublishMode = "async"
+	// PublishSync waits for the broker ack (RequireAll) and returns 503
+	// with Retry-After if the publish fails.
+	PublishSync PublishMode = "sync"
+	// PublishSpool appends the event to the local spool, fsynced, and
+	// returns 202 without waiting for the broker; the spool drainer
+	// publishes it. A 202 survives a broker outage or a crash of the API,
+	// and the event reaches Kafka up to a drain interval later. A full or
+	// failing spool returns 503 with Retry-After.
+	PublishSpool PublishMode = "spool"
+)
+
+// ParsePublishMode validates a configured publish mode.
+func ParsePublishMode(s string) (PublishMode, error) {
+	switch m := PublishMode(s); m {
+	case PublishAsync, PublishSync, PublishSpool:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown publish mode %q", s)
+	}
+}
+
+// Spooler durably stores events that could not be published so they can
+// be re-published once the broker is reachable again.
+type Spooler interface {
+	Append(key string, value []byte) error
+}
+
+// IngestOptions configures HandleEvent.
+type IngestOptions struct {
+	Mode           PublishMode
+	PublishTimeout time.Duration // Broker ack deadline (default 5s)
+	RetryAfter     time.Duration // Retry-After on 503 (default 5s)
+	Spool          Spooler       // Publish-failure fallback; the write path for PublishSpool
+	MaxBatchEvents int           // Max events per POST /v1/events/batch (default 500)
+	// StreamBatchEvents is how many events POST /v1/events/stream
+	// publishes per broker round-trip (default 500).
+	StreamBatchEvents int
+	// Schemas validates payloads against the active schema of their
+	// event type. Nil disables payload validation.
+	Schemas *schema.Registry
+	// MaxFutureSkew is how far ahead of the server clock occurred_at may
+	// be (default 5m). MaxLateness is how old it may be; 0 accepts any age.
+	MaxFutureSkew time.Duration
+	MaxLateness   time.Duration
+	// GenerateIDs mints a time-ordered UUIDv7 for events sent without an
+	// event_id. Client-supplied IDs are always kept so retries stay
+	// idempotent.
+	GenerateIDs bool
+	// Quota charges accepted events to their tenant's daily quota. Nil
+	// disables quotas.
+	Quota *quota.Limiter
+	// RateLimit takes n more tokens from the request's rate limits, for
+	// batches and streams: the middleware charges one per request, these
+	// charge one per event. It returns false and how long to wait when a
+	// limit is used up. Nil disables it.
+	RateLimit func(r *http.Request, n int) (retryAfter time.Duration, ok bool)
+	// MaxBodyBytes caps the body of POST /v1/events and each event of
+	// POST /v1/events/stream (default 1 MiB); MaxBatchBodyBytes caps the
+	// body of POST /v1/events/batch (default 16 MiB). Larger requests get
+	// 413.
+	MaxBodyBytes      int64
+	MaxBatchBodyBytes int64
+	// MaxPayloadBytes caps an event's payload (default 64 KiB).
+	MaxPayloadBytes int
+	// MaxJSONDepth is how deeply an event's objects and arrays may nest,
+	// counting the event itself (default 32).
+	MaxJSONDepth int
+	// StrictJSON rejects events with fields EventRequest does not define
+	// instead of ignoring them.
+	StrictJSON bool
+	// InFlight tracks async publishes that outlive their request, so
+	// shutdown can wait for them before closing the producer.
+	InFlight *sync.WaitGroup
+	// Logger reports async publishes that failed after their 202.
+	Logger *logging.Logger
+}
+
+// errSchemaUnavailable marks validation that could not run because the
+// schema registry could not be loaded.
+var errSchemaUnavailable = errors.New("schema registry unavailable")
+
+// errQuotaExceeded marks events refused because their tenant used up its
+// daily quota.
+var errQuotaExceeded = errors.New("daily event quota exceeded")
+
+// errRateLimited marks events refused because a rate limit of their
+// request is used up.
+var errRateLimited = errors.New("rate limit exceeded")
+
+func HandleEvent(producer *messaging.Producer, opts IngestOptions) http.HandlerFunc {
+	opts = opts.withDefaults()
+	retryAfter := opts.retryAfterSeconds()
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req EventRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "invalid json", http.StatusBadRequest)
+		err := decodeBody(w, r, opts.MaxBodyBytes, opts.MaxJSONDepth, opts.StrictJSON, &req)
+		if err == nil {
+			err = opts.validateEvent(r.Context(), &req)
+		}
+		if err != nil {
+			status := writeEventError(w, r, err, retryAfter)
+			if status >= 500 {
+				countEvents("single", 0, 0, 1)
+			} else {
+				countEvents("single", 0, 1, 0)
+			}
+			return
+		}
+		if quotaRetryAfter, ok := opts.chargeQuota(r.Context(), req.TenantID, 1); !ok {
+			rejectOverQuota(w, r, "single", 1, quotaRetryAfter)
 			return
 		}
 
-		if _, err := uuid.Parse(req.EventID); err != nil {
-			http.Error(w, "invalid event_id", http.StatusBadRequest)
+		switch opts.Mode {
+		case PublishAsync:
+			// Publish event asynchronously to Kafka without blocking the response.
+			// The publish outlives the request but stays in its trace.
+			ctx := context.WithoutCancel(r.Context())
+			opts.InFlight.Add(1)
+			go func() {
+				defer opts.InFlight.Done()
+				ctx, cancel := context.WithTimeout(ctx, opts.PublishTimeout)
+				defer cancel()
+				err := producer.Publish(ctx, req.key(), req)
+				if err != nil && opts.Spool != nil {
+					err = spoolEvent(opts.Spool, req)
+				}
+				if err != nil {
+					// The client already has its 202; all that is left is
+					// to make the loss visible.
+					opts.refundQuota(req.TenantID, 1)
+					countEvents("single", 0, 0, 1)
+					opts.Logger.WithContext(ctx).Error("accepted event lost", map[string]any{
+						"event_id":  req.EventID,
+						"tenant_id": req.TenantID,
+						"error":     err.Error(),
+					})
+					return
+				}
+				// Counted only now, so an event lost after its 202 is never
+				// in both the accepted and the rejected series.
+				countEvents("single", 1, 0, 0)
+			}()
+			writeAccepted(w, req.EventID)
 			return
+
+		case PublishSpool:
+			if err := spoolEvent(opts.Spool, req); err != nil {
+				opts.refundQuota(req.TenantID, 1)
+				countEvents("single", 0, 0, 1)
+				w.Header().Set("Retry-After", retryAfter)
+				apierror.Write(w, r, http.StatusServiceUnavailable, apierror.CodeSpoolUnavailable, "event not accepted, spool unavailable", nil)
+				return
+			}
+
+		case PublishSync:
+			ctx, cancel := context.WithTimeout(r.Context(), opts.PublishTimeout)
+			err := producer.Publish(ctx, req.key(), req)
+			cancel()
+			if err != nil {
+				opts.refundQuota(req.TenantID, 1)
+				countEvents("single", 0, 0, 1)
+				w.Header().Set("Retry-After", retryAfter)
+				apierror.Write(w, r, http.StatusServiceUnavailable, apierror.CodeBrokerUnavailable, "event not accepted, broker unavailable", nil)
+				return
+			}
+		}
+
+		countEvents("single", 1, 0, 0)
+		writeAccepted(w, req.EventID)
+	}
+}
+
+// writeAccepted acknowledges a single event with 202.
+func writeAccepted(w http.ResponseWriter, eventID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "accepted",
+		"event_id": eventID,
+		"message":  "Event accepted for processing",
+	})
+}
+
+// validateEvent checks the fields the pipeline relies on and, when a
+// registry is configured, the payload against its active schema. On
+// success req.SchemaVersion holds the version used (0 if none),
+// req.TenantID the tenant ctx acts for, and req.EventID is set, generated
+// if the client omitted it and GenerateIDs is on.
+func (o IngestOptions) validateEvent(ctx context.Context, req *EventRequest) error {
+	req.SchemaVersion = 0
+	req.TenantID = tenant.Default
+	if id, ok := tenant.From(ctx); ok {
+		req.TenantID = id
+	}
+	if req.EventID == "" && o.GenerateIDs {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return fmt.Errorf("generate event_id: %w", err)
 		}
+		req.EventID = id.String()
+	}
+	if _, err := uuid.Parse(req.EventID); err != nil {
+		return badRequest(apierror.CodeInvalidEventID, "invalid event_id")
+	}
+	if err := o.checkOccurredAt(req.OccurredAt, time.Now()); err != nil {
+		return err
+	}
+	if len(req.Payload) > o.MaxPayloadBytes {
+		return &apierror.Error{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    apierror.CodePayloadTooLarge,
+			Message: fmt.Sprintf("payload exceeds %d bytes", o.MaxPayloadBytes),
+		}
+	}
+	if o.Schemas == nil {
+		return nil
+	}
 
-		// Publish event asynchronously to Kafka without blocking the response
-		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			producer.Publish(ctx, req.EventID, req)
-		}()
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusAccepted)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status":   "accepted",
-			"event_id": req.EventID,
-			"message":  "Event accepted for processing",
-		})
+	version, err := o.Schemas.Validate(ctx, req.Type, req.Payload)
+	var ve *schema.ValidationError
+	if err != nil && !errors.As(err, &ve) {
+		return fmt.Errorf("%w: %v", errSchemaUnavailable, err)
 	}
+	if err != nil {
+		return err
+	}
+	req.SchemaVersion = version
+	return nil
+}
+
+// checkOccurredAt rejects client timestamps outside the accepted window
+// around now.
+func (o IngestOptions) checkOccurredAt(t *time.Time, now time.Time) error {
+	if t == nil {
+		return nil
+	}
+	if t.After(now.Add(o.MaxFutureSkew)) {
+		return badRequest(apierror.CodeInvalidOccurredAt, fmt.Sprintf("occurred_at is more than %s in the future", o.MaxFutureSkew))
+	}
+	if o.MaxLateness > 0 && t.Before(now.Add(-o.MaxLateness)) {
+		return badRequest(apierror.CodeInvalidOccurredAt, fmt.Sprintf("occurred_at is more than %s in the past", o.MaxLateness))
+	}
+	return nil
+}
+
+// writeEventError responds to an event that could not be decoded or
+// validated and returns the status used. Schema mismatches carry the
+// field-level errors in details; 503s carry Retry-After.
+func writeEventError(w http.ResponseWriter, r *http.Request, err error, retryAfter string) int {
+	status, code, details := errorCode(err)
+	message := err.Error()
+	switch status {
+	case http.StatusServiceUnavailable:
+		w.Header().Set("Retry-After", retryAfter)
+		message = errSchemaUnavailable.Error()
+	case http.StatusInternalServerError:
+		message = "failed to process event"
+	}
+	apierror.Write(w, r, status, code, message, details)
+	return status
+}
+
+// RejectedQuota is the events_rejected_total reason for events refused
+// because their tenant used up its daily quota.
+const RejectedQuota = "quota_exceeded"
+
+// chargeQuota charges n events to tenantID's daily quota. When the quota
+// is used up it returns false and the Retry-After value until it renews.
+func (o IngestOptions) chargeQuota(ctx context.Context, tenantID string, n int) (string, bool) {
+	if o.Quota == nil || n == 0 {
+		return "", true
+	}
+	ok, reset := o.Quota.Allow(ctx, tenantID, n)
+	if ok {
+		return "", true
+	}
+	return strconv.Itoa(int(math.Ceil(time.Until(reset).Seconds()))), false
+}
+
+// refundQuota returns n charged events that were not accepted, so tenants
+// are only charged for events that reach Kafka or the spool.
+func (o IngestOptions) refundQuota(tenantID string, n int) {
+	if o.Quota == nil || n == 0 {
+		return
+	}
+	o.Quota.Refund(tenantID, n)
+}
+
+// takeRateLimit charges n more events to r's rate limits. When one is
+// used up it returns false and the Retry-After value.
+func (o IngestOptions) takeRateLimit(r *http.Request, n int) (string, bool) {
+	if o.RateLimit == nil || n <= 0 {
+		return "", true
+	}
+	retryAfter, ok := o.RateLimit(r, n)
+	if ok {
+		return "", true
+	}
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))), false
+}
+
+// rejectOverQuota responds 429 to a request whose n events would exceed
+// the tenant's daily quota.
+func rejectOverQuota(w http.ResponseWriter, r *http.Request, endpoint string, n int, retryAfter string) {
+	metrics.EventsRejected.WithLabelValues(endpoint, RejectedQuota).Add(float64(n))
+	w.Header().Set("Retry-After", retryAfter)
+	apierror.Write(w, r, http.StatusTooManyRequests, apierror.CodeQuotaExceeded, errQuotaExceeded.Error(), nil)
+}
+
+// countEvents records ingestion outcomes for an endpoint.
+func countEvents(endpoint string, accepted, invalid, failed int) {
+	metrics.EventsAccepted.WithLabelValues(endpoint).Add(float64(accepted))
+	metrics.EventsRejected.WithLabelValues(endpoint, ItemInvalid).Add(float64(invalid))
+	metrics.EventsRejected.WithLabelValues(endpoint, ItemFailed).Add(float64(failed))
+}
+
+// rejectedItem builds the batch/stream result for an event that failed
+// validation.
+func rejectedItem(index int, eventID string, err error) BatchItemResult {
+	status, code, _ := errorCode(err)
+	res := BatchItemResult{Index: index, EventID: eventID, Status: ItemInvalid, Code: code, Reason: err.Error()}
+	var ve *schema.ValidationError
+	if errors.As(err, &ve) {
+		res.Fields = ve.Fields
+	}
+	if status >= 500 {
+		res.Status = ItemFailed
+		if errors.Is(err, errSchemaUnavailable) {
+			res.Reason = errSchemaUnavailable.Error()
+		}
+	}
+	return res
+}
+
+func (o IngestOptions) withDefaults() IngestOptions {
+	if o.Mode == "" {
+		o.Mode = PublishAsync
+	}
+	if o.PublishTimeout <= 0 {
+		o.PublishTimeout = 5 * time.Second
+	}
+	if o.RetryAfter <= 0 {
+		o.RetryAfter = 5 * time.Second
+	}
+	if o.InFlight == nil {
+		o.InFlight = &sync.WaitGroup{}
+	}
+	if o.Logger == nil {
+		o.Logger = logging.New("ingestion-api", "info")
+	}
+	if o.MaxFutureSkew <= 0 {
+		o.MaxFutureSkew = 5 * time.Minute
+	}
+	if o.MaxBatchEvents <= 0 {
+		o.MaxBatchEvents = 500
+	}
+	if o.StreamBatchEvents <= 0 {
+		o.StreamBatchEvents = 500
+	}
+	if o.MaxBodyBytes <= 0 {
+		o.MaxBodyBytes = 1 << 20
+	}
+	if o.MaxBatchBodyBytes <= 0 {
+		o.MaxBatchBodyBytes = 16 << 20
+	}
+	if o.MaxPayloadBytes <= 0 {
+		o.MaxPayloadBytes = 64 << 10
+	}
+	if o.MaxJSONDepth <= 0 {
+		o.MaxJSONDepth = 32
+	}
+	return o
+}
+
+// retryAfterSeconds formats RetryAfter for the Retry-After header.
+func (o IngestOptions) retryAfterSeconds() string {
+	return strconv.Itoa(int(math.Ceil(o.RetryAfter.Seconds())))
+}
+
+// publishFailure is the code and reason reported for events publishBatch
+// did not accept.
+func (o IngestOptions) publishFailure() (string, string) {
+	if o.Mode == PublishSpool {
+		return apierror.CodeSpoolUnavailable, "spool unavailable"
+	}
+	return apierror.CodeBrokerUnavailable, "broker unavailable"
+}
+
+// spoolEvent serialises req exactly as Producer.Publish would and appends
+// it to the spool.
+func spoolEvent(spool Spooler, req EventRequest) error {
+	value, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return spool.Append(req.key(), value)
+}
+
+// key is the Kafka message key of a validated event.
+func (req EventRequest) key() string {
+	return messaging.EventKey(req.TenantID, req.EventID)
 }
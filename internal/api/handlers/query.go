@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
 	"github.com/go-chi/chi/v5"
 )
@@ -17,8 +18,12 @@ type QueryHandlers struct {
 
 // ListEvents handles GET /v1/events with optional query params:
 //
-//	?type=click&limit=50&offset=0&from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z
+//	?type=click&limit=50&offset=0&from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z&time_axis=occurred
 func (q *QueryHandlers) ListEvents(w http.ResponseWriter, r *http.Request) {
+	axis, ok := timeAxis(w, r)
+	if !ok {
+		return
+	}
 	eventType := r.URL.Query().Get("type")
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
@@ -42,9 +47,9 @@ func (q *QueryHandlers) ListEvents(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	events, total, err := q.DB.GetEvents(r.Context(), eventType, from, to, limit, offset)
+	events, total, err := q.DB.GetEvents(r.Context(), eventType, axis, from, to, limit, offset)
 	if err != nil {
-		http.Error(w, "failed to query events", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to query events", nil)
 		return
 	}
 	if events == nil {
@@ -65,11 +70,11 @@ func (q *QueryHandlers) GetEvent(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	event, err := q.DB.GetEvent(r.Context(), id)
 	if err != nil {
-		http.Error(w, "failed to get event", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to get event", nil)
 		return
 	}
 	if event == nil {
-		http.Error(w, "event not found", http.StatusNotFound)
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "event not found", nil)
 		return
 	}
 
@@ -81,7 +86,7 @@ func (q *QueryHandlers) GetEvent(w http.ResponseWriter, r *http.Request) {
 func (q *QueryHandlers) GetSummary(w http.ResponseWriter, r *http.Request) {
 	summary, err := q.DB.GetSummary(r.Context())
 	if err != nil {
-		http.Error(w, "failed to get summary", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to get summary", nil)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -92,7 +97,7 @@ func (q *QueryHandlers) GetSummary(w http.ResponseWriter, r *http.Request) {
 func (q *QueryHandlers) GetTypeCounts(w http.ResponseWriter, r *http.Request) {
 	counts, err := q.DB.GetTypeCounts(r.Context())
 	if err != nil {
-		http.Error(w, "failed to get type counts", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to get type counts", nil)
 		return
 	}
 	if counts == nil {
@@ -102,15 +107,19 @@ func (q *QueryHandlers) GetTypeCounts(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(counts)
 }
 
-// GetTimeline handles GET /v1/analytics/timeline?hours=24
+// GetTimeline handles GET /v1/analytics/timeline?hours=24&time_axis=occurred
 func (q *QueryHandlers) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	axis, ok := timeAxis(w, r)
+	if !ok {
+		return
+	}
 	hours, _ := strconv.Atoi(r.URL.Query().Get("hours"))
 	if hours <= 0 || hours > 720 {
 		hours = 24
 	}
-	points, err := q.DB.GetTimeline(r.Context(), hours)
+	points, err := q.DB.GetTimeline(r.Context(), axis, hours)
 	if err != nil {
-		http.Error(w, "failed to get timeline", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to get timeline", nil)
 		return
 	}
 	if points == nil {
@@ -119,3 +128,17 @@ func (q *QueryHandlers) GetTimeline(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(points)
 }
+
+// timeAxis parses ?time_axis=received|occurred (default received), writing
+// a 400 on failure.
+func timeAxis(w http.ResponseWriter, r *http.Request) (storage.TimeAxis, bool) {
+	switch v := storage.TimeAxis(r.URL.Query().Get("time_axis")); v {
+	case "":
+		return storage.AxisReceived, true
+	case storage.AxisReceived, storage.AxisOccurred:
+		return v, true
+	default:
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, "time_axis must be received or occurred", nil)
+		return "", false
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/replay"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// BulkReplayRequest selects DLQ events to replay. Only events that have
+// never been replayed are selected unless IncludeReplayed is set.
+type BulkReplayRequest struct {
+	ErrorKind       string     `json:"error_kind"`
+	Topic           string     `json:"topic"`
+	Type            string     `json:"event_type"`
+	From            *time.Time `json:"from"`
+	To              *time.Time `json:"to"`
+	IncludeReplayed bool       `json:"include_replayed"`
+	Limit           int        `json:"limit"`
+	Force           bool       `json:"force"`
+}
+
+// HandleDLQReplay handles POST /v1/dlq/{id}/replay?force=true
+//
+// Responds 200 with the result, 502 when the broker refused the event
+// and 500 for storage or data errors. An event that was re-published but
+// could not be marked replayed gets 202 with the result: it is back on
+// the main topic, so retrying would publish it twice.
+func HandleDLQReplay(replayer *replay.Replayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, "invalid dlq id", nil)
+			return
+		}
+		force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+		res, err := replayer.ReplayOne(r.Context(), id, force)
+		switch {
+		case errors.Is(err, replay.ErrNotFound):
+			apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "dlq event not found", nil)
+			return
+		case errors.Is(err, replay.ErrPermanent):
+			apierror.Write(w, r, http.StatusConflict, apierror.CodeConflict, err.Error(), nil)
+			return
+		case errors.Is(err, replay.ErrPublish):
+			apierror.Write(w, r, http.StatusBadGateway, apierror.CodeBrokerUnavailable, "failed to publish dlq event", nil)
+			return
+		case errors.Is(err, replay.ErrNotMarked):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(res)
+			return
+		case err != nil:
+			apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to replay dlq event", nil)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+// HandleDLQBulkReplay handles POST /v1/dlq/replay with a BulkReplayRequest body.
+func HandleDLQBulkReplay(replayer *replay.Replayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req BulkReplayRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidJSON, "invalid json", nil)
+			return
+		}
+		if req.Limit <= 0 || req.Limit > 1000 {
+			req.Limit = 100
+		}
+
+		filter := storage.DLQFilter{
+			ErrorKind:     req.ErrorKind,
+			OriginalTopic: req.Topic,
+			EventType:     req.Type,
+			From:          req.From,
+			To:            req.To,
+		}
+		if !req.IncludeReplayed {
+			replayed := false
+			filter.Replayed = &replayed
+		}
+
+		results, err := replayer.ReplayMatching(r.Context(), filter, req.Limit, req.Force)
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to query dlq events", nil)
+			return
+		}
+
+		counts := map[string]int{}
+		for _, res := range results {
+			counts[res.Status]++
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results":  results,
+			"replayed": counts[replay.StatusReplayed],
+			"skipped":  counts[replay.StatusSkipped],
+			"failed":   counts[replay.StatusFailed],
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/schema"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// SchemaHandlers manage the event schema registry under /v1/schemas.
+type SchemaHandlers struct {
+	DB       *storage.DB
+	Registry *schema.Registry // invalidated after every change
+}
+
+// CreateSchemaRequest is the body of POST /v1/schemas/{event_type}.
+type CreateSchemaRequest struct {
+	Schema   json.RawMessage `json:"schema"`
+	Activate bool            `json:"activate"`
+}
+
+// ListSchemas handles GET /v1/schemas?event_type=purchase
+func (h *SchemaHandlers) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, r.URL.Query().Get("event_type"))
+}
+
+// ListTypeSchemas handles GET /v1/schemas/{event_type}
+func (h *SchemaHandlers) ListTypeSchemas(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, chi.URLParam(r, "event_type"))
+}
+
+func (h *SchemaHandlers) list(w http.ResponseWriter, r *http.Request, eventType string) {
+	schemas, err := h.DB.ListSchemas(r.Context(), eventType)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to list schemas", nil)
+		return
+	}
+	if schemas == nil {
+		schemas = []storage.EventSchema{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas)
+}
+
+// CreateSchema handles POST /v1/schemas/{event_type}. The schema is stored
+// as the next version and activated if requested.
+func (h *SchemaHandlers) CreateSchema(w http.ResponseWriter, r *http.Request) {
+	eventType := chi.URLParam(r, "event_type")
+
+	var req CreateSchemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidJSON, "invalid json", nil)
+		return
+	}
+	if len(req.Schema) == 0 {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidField, "schema is required", apierror.FieldDetails{Field: "schema"})
+		return
+	}
+	if _, err := schema.Compile(eventType, 0, req.Schema); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidField, "invalid schema: "+err.Error(), apierror.FieldDetails{Field: "schema"})
+		return
+	}
+
+	created, err := h.DB.CreateSchema(r.Context(), eventType, req.Schema, req.Activate)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to create schema", nil)
+		return
+	}
+	h.invalidate()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetSchema handles GET /v1/schemas/{event_type}/{version}
+func (h *SchemaHandlers) GetSchema(w http.ResponseWriter, r *http.Request) {
+	eventType, version, ok := schemaParams(w, r)
+	if !ok {
+		return
+	}
+
+	s, err := h.DB.GetSchema(r.Context(), eventType, version)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to get schema", nil)
+		return
+	}
+	if s == nil {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "schema not found", nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+// ActivateSchema handles POST /v1/schemas/{event_type}/{version}/activate
+func (h *SchemaHandlers) ActivateSchema(w http.ResponseWriter, r *http.Request) {
+	eventType, version, ok := schemaParams(w, r)
+	if !ok {
+		return
+	}
+
+	found, err := h.DB.ActivateSchema(r.Context(), eventType, version)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to activate schema", nil)
+		return
+	}
+	if !found {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "schema not found", nil)
+		return
+	}
+	h.invalidate()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeactivateSchema handles POST /v1/schemas/{event_type}/deactivate and
+// turns off payload validation for the type.
+func (h *SchemaHandlers) DeactivateSchema(w http.ResponseWriter, r *http.Request) {
+	if err := h.DB.DeactivateSchema(r.Context(), chi.URLParam(r, "event_type")); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to deactivate schema", nil)
+		return
+	}
+	h.invalidate()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteSchema handles DELETE /v1/schemas/{event_type}/{version}. Only
+// inactive versions can be deleted.
+func (h *SchemaHandlers) DeleteSchema(w http.ResponseWriter, r *http.Request) {
+	eventType, version, ok := schemaParams(w, r)
+	if !ok {
+		return
+	}
+
+	found, err := h.DB.DeleteSchema(r.Context(), eventType, version)
+	switch {
+	case errors.Is(err, storage.ErrSchemaActive):
+		apierror.Write(w, r, http.StatusConflict, apierror.CodeConflict, "cannot delete the active schema version", nil)
+		return
+	case err != nil:
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to delete schema", nil)
+		return
+	case !found:
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "schema not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SchemaHandlers) invalidate() {
+	if h.Registry != nil {
+		h.Registry.Invalidate()
+	}
+}
+
+// schemaParams parses {event_type} and {version}, writing a 400 on failure.
+func schemaParams(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version <= 0 {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, "invalid schema version", nil)
+		return "", 0, false
+	}
+	return chi.URLParam(r, "event_type"), version, true
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/messaging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+)
+
+// StreamProgress is one NDJSON line written back by HandleEventStream.
+// A "progress" line follows every published batch; the final line has
+// type "summary".
+type StreamProgress struct {
+	Type     string            `json:"type"` // "progress" | "summary"
+	Received int               `json:"received"`
+	Accepted int               `json:"accepted"`
+	Invalid  int               `json:"invalid"`
+	Failed   int               `json:"failed"`
+	Errors   []BatchItemResult `json:"errors,omitempty"` // non-accepted items of this batch
+	Error    string            `json:"error,omitempty"`  // why the stream stopped early
+	Code     string            `json:"code,omitempty"`   // Error's code
+	// ResumeFrom is the index of the first event not processed when the
+	// stream stopped early, so clients can restart a backfill from there.
+	ResumeFrom *int `json:"resume_from,omitempty"`
+}
+
+// HandleEventStream handles POST /v1/events/stream with a newline-delimited
+// JSON body of arbitrary size.
+//
+// Events are decoded one at a time and published in batches of
+// StreamBatchEvents, waiting for the broker ack between batches so a slow
+// broker applies backpressure to the upload. Progress is streamed back as
+// NDJSON. A JSON syntax error or a batch in which no event could be
+// published, or that would exceed a rate limit (one token per event) or
+// the tenant's daily quota, stops the stream with a summary carrying
+// resume_from. So does an event larger than MaxBodyBytes, since the body
+// itself is unbounded.
+func HandleEventStream(producer *messaging.Producer, opts IngestOptions) http.HandlerFunc {
+	opts = opts.withDefaults()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// HTTP/1.x servers stop reading the body once the response starts
+		// unless full duplex is enabled; progress lines go out mid-upload.
+		rc := http.NewResponseController(w)
+		rc.EnableFullDuplex()
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+
+		enc := json.NewEncoder(w)
+		emit := func(p StreamProgress) {
+			enc.Encode(p)
+			rc.Flush()
+		}
+
+		var total StreamProgress
+		body := &budgetReader{r: r.Body}
+		dec := json.NewDecoder(body)
+
+		var reqs []EventRequest
+		var positions []int
+		var itemErrs []BatchItemResult
+		prepaid := 1 // the rate limit middleware charged the first event
+
+		// flush publishes the pending batch and reports it. It returns why
+		// the stream must stop: a rate limit or the tenant's quota cannot take
+		// the batch, or nothing in it could be published. nil continues.
+		flush := func() *apierror.Error {
+			batch := StreamProgress{Type: "progress", Errors: itemErrs}
+			for _, e := range itemErrs {
+				if e.Status == ItemFailed {
+					batch.Failed++
+				} else {
+					batch.Invalid++
+				}
+			}
+			var stopCause *apierror.Error
+			overQuota, rateLimited := 0, 0
+			if len(reqs) > 0 {
+				code, reason := opts.publishFailure()
+				cause := &apierror.Error{Code: code, Message: reason}
+				charge := max(len(reqs)-prepaid, 0)
+				prepaid = max(prepaid-len(reqs), 0)
+				var errs []error
+				if _, ok := opts.takeRateLimit(r, charge); !ok {
+					rateLimited = len(reqs)
+					cause = &apierror.Error{Code: apierror.CodeRateLimited, Message: errRateLimited.Error()}
+					errs = slices.Repeat([]error{errRateLimited}, len(reqs))
+				} else if _, ok := opts.chargeQuota(r.Context(), reqs[0].TenantID, len(reqs)); !ok {
+					overQuota = len(reqs)
+					cause = &apierror.Error{Code: apierror.CodeQuotaExceeded, Message: errQuotaExceeded.Error()}
+					errs = slices.Repeat([]error{errQuotaExceeded}, len(reqs))
+				} else {
+					errs = publishBatch(r.Context(), producer, opts, reqs)
+				}
+				for j, err := range errs {
+					if err != nil {
+						batch.Failed++
+						batch.Errors = append(batch.Errors, BatchItemResult{
+							Index: positions[j], EventID: reqs[j].EventID,
+							Status: ItemFailed, Code: cause.Code, Reason: cause.Message,
+						})
+						continue
+					}
+					batch.Accepted++
+				}
+				if overQuota == 0 && rateLimited == 0 {
+					opts.refundQuota(reqs[0].TenantID, len(reqs)-batch.Accepted)
+				}
+				if batch.Accepted == 0 {
+					stopCause = cause
+				}
+			}
+
+			// Rate-limited events are not failures; the refusal is counted in
+			// rate_limited_requests_total.
+			countEvents("stream", batch.Accepted, batch.Invalid, batch.Failed-overQuota-rateLimited)
+			metrics.EventsRejected.WithLabelValues("stream", RejectedQuota).Add(float64(overQuota))
+			total.Accepted += batch.Accepted
+			total.Invalid += batch.Invalid
+			total.Failed += batch.Failed
+			batch.Received = total.Received
+			emit(batch)
+
+			reqs, positions, itemErrs = reqs[:0], positions[:0], nil
+			return stopCause
+		}
+
+		stop := func(cause *apierror.Error, resumeFrom int) {
+			total.Type = "summary"
+			total.Error, total.Code = cause.Message, cause.Code
+			total.ResumeFrom = &resumeFrom
+			emit(total)
+		}
+
+		// flushOrStop flushes the pending batch, stopping the stream if the
+		// broker took none of it. Returns false when the stream stopped.
+		flushOrStop := func() bool {
+			start := total.Received
+			if len(positions) > 0 {
+				start = positions[0]
+			}
+			if cause := flush(); cause != nil {
+				stop(cause, start)
+				return false
+			}
+			return true
+		}
+
+		for {
+			var raw json.RawMessage
+			body.left = opts.MaxBodyBytes
+			err := dec.Decode(&raw)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				// The decoder cannot resynchronise after a syntax error.
+				index := total.Received
+				cause := badRequest(apierror.CodeInvalidJSON, "invalid json: "+err.Error())
+				if errors.Is(err, errEventTooLarge) {
+					cause = &apierror.Error{Code: apierror.CodeEventTooLarge, Message: fmt.Sprintf("event exceeds %d bytes", opts.MaxBodyBytes)}
+				}
+				if flushOrStop() {
+					stop(cause, index)
+				}
+				return
+			}
+
+			index := total.Received
+			total.Received++
+
+			var req EventRequest
+			if err := decodeJSON(raw, opts.MaxJSONDepth, opts.StrictJSON, &req); err != nil {
+				itemErrs = append(itemErrs, rejectedItem(index, "", err))
+			} else if err := opts.validateEvent(r.Context(), &req); err != nil {
+				itemErrs = append(itemErrs, rejectedItem(index, req.EventID, err))
+			} else {
+				reqs = append(reqs, req)
+				positions = append(positions, index)
+			}
+
+			if len(reqs)+len(itemErrs) >= opts.StreamBatchEvents {
+				if !flushOrStop() {
+					return
+				}
+			}
+			if r.Context().Err() != nil {
+				return
+			}
+		}
+
+		if len(reqs) > 0 || len(itemErrs) > 0 {
+			if !flushOrStop() {
+				return
+			}
+		}
+		total.Type = "summary"
+		emit(total)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/google/uuid"
+)
+
+func TestCheckOccurredAt(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	tests := []struct {
+		name        string
+		occurredAt  *time.Time
+		maxLateness time.Duration
+		ok          bool
+	}{
+		{"absent", nil, time.Hour, true},
+		{"now", at(0), time.Hour, true},
+		{"at the future skew", at(5 * time.Minute), time.Hour, true},
+		{"past the future skew", at(5*time.Minute + time.Second), time.Hour, false},
+		{"at the lateness window", at(-time.Hour), time.Hour, true},
+		{"past the lateness window", at(-time.Hour - time.Second), time.Hour, false},
+		{"any age without a window", at(-365 * 24 * time.Hour), 0, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := IngestOptions{MaxLateness: tc.maxLateness}.withDefaults()
+			err := opts.checkOccurredAt(tc.occurredAt, now)
+			if tc.ok {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var apiErr *apierror.Error
+			if !errors.As(err, &apiErr) || apiErr.Code != apierror.CodeInvalidOccurredAt {
+				t.Errorf("expected %s, got %v", apierror.CodeInvalidOccurredAt, err)
+			}
+		})
+	}
+}
+
+func TestValidateEvent_GenerateIDs(t *testing.T) {
+	const clientID = "0190a0d4-7a5c-7000-8000-000000000001"
+	tests := []struct {
+		name        string
+		eventID     string
+		generateIDs bool
+		code        string // "" expects success
+	}{
+		{"generated when omitted", "", true, ""},
+		{"client ID kept", clientID, true, ""},
+		{"required when generation is off", "", false, apierror.CodeInvalidEventID},
+		{"client ID still validated", "not-a-uuid", true, apierror.CodeInvalidEventID},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := IngestOptions{GenerateIDs: tc.generateIDs}.withDefaults()
+			req := EventRequest{EventID: tc.eventID, Type: "click", Payload: []byte("{}")}
+			err := opts.validateEvent(context.Background(), &req)
+			if tc.code != "" {
+				var apiErr *apierror.Error
+				if !errors.As(err, &apiErr) || apiErr.Code != tc.code {
+					t.Errorf("expected %s, got %v", tc.code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.eventID != "" {
+				if req.EventID != tc.eventID {
+					t.Errorf("expected the client's event_id to be kept, got %s", req.EventID)
+				}
+				return
+			}
+			id, err := uuid.Parse(req.EventID)
+			if err != nil || id.Version() != 7 {
+				t.Errorf("expected a UUIDv7 event_id, got %q", req.EventID)
+			}
+		})
+	}
+}
+
+func TestValidateEvent_GeneratedIDsAreTimeOrdered(t *testing.T) {
+	opts := IngestOptions{GenerateIDs: true}.withDefaults()
+	var prev string
+	for i := 0; i < 100; i++ {
+		req := EventRequest{Type: "click", Payload: []byte("{}")}
+		if err := opts.validateEvent(context.Background(), &req); err != nil {
+			t.Fatalf("validate: %v", err)
+		}
+		if req.EventID <= prev {
+			t.Fatalf("expected increasing IDs, got %s after %s", req.EventID, prev)
+		}
+		prev = req.EventID
+	}
+}
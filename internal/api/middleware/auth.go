@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/auth"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tenant"
+)
+
+// Authenticate resolves the key in "Authorization: Bearer <key>" or
+// X-API-Key and puts it and its tenant in the request context. With a nil
+// Authenticator authentication is off: every request acts for the default
+// tenant with the admin and operator scopes.
+func Authenticate(a *auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var key *auth.Key
+			if a == nil {
+				key = &auth.Key{TenantID: tenant.Default, Scopes: []string{string(auth.ScopeAdmin), string(auth.ScopeOperator)}}
+			} else {
+				var err error
+				key, err = a.Authenticate(r.Context(), presentedKey(r))
+				switch {
+				case errors.Is(err, auth.ErrInvalidKey):
+					w.Header().Set("WWW-Authenticate", `Bearer realm="events"`)
+					apierror.Write(w, r, http.StatusUnauthorized, apierror.CodeUnauthorized, "missing or invalid api key", nil)
+					return
+				case err != nil:
+					apierror.Write(w, r, http.StatusServiceUnavailable, apierror.CodeAuthUnavailable, "failed to verify api key", nil)
+					return
+				}
+			}
+
+			ctx := auth.WithKey(r.Context(), key)
+			ctx = tenant.With(ctx, key.TenantID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope rejects requests whose key does not grant scope with 403.
+// It must run after Authenticate.
+func RequireScope(scope auth.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := auth.FromContext(r.Context())
+			if !ok || !key.Has(scope) {
+				apierror.Write(w, r, http.StatusForbidden, apierror.CodeForbidden, "api key lacks the "+string(scope)+" scope", nil)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func presentedKey(r *http.Request) string {
+	if v := r.Header.Get("Authorization"); v != "" {
+		if token, ok := strings.CutPrefix(v, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return r.Header.Get("X-API-Key")
+}
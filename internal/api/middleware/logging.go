@@ -5,25 +5,27 @@ import (
 	"time"
 
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
+	chimw "github.com/go-chi/chi/v5/middleware"
 )
 
 func Logging(logger *logging.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			log := logger.WithContext(r.Context())
 
-			reqID, _ := r.Context().Value(RequestIDKey).(string)
-
-			logger.Info("request started", map[string]any{
-				"request_id": reqID,
-				"method":     r.Method,
-				"path":       r.URL.Path,
+			log.Debug("request started", map[string]any{
+				"method": r.Method,
+				"path":   r.URL.Path,
 			})
 
-			next.ServeHTTP(w, r)
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
 
-			logger.Info("request completed", map[string]any{
-				"request_id": reqID,
+			log.Info("request completed", map[string]any{
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"status":      responseStatus(ww),
 				"duration_ms": time.Since(start).Milliseconds(),
 			})
 		})
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// Metrics records request duration by chi route pattern, so paths with
+// IDs collapse into one series.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		metrics.HTTPRequestDuration.
+			WithLabelValues(r.Method, routePattern(r), strconv.Itoa(responseStatus(ww))).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+// routePattern returns the chi pattern the request matched, once the
+// router has run.
+func routePattern(r *http.Request) string {
+	if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+		return rc.RoutePattern()
+	}
+	return "unmatched"
+}
+
+// responseStatus treats a handler that never called WriteHeader as 200.
+func responseStatus(ww chimw.WrapResponseWriter) int {
+	if status := ww.Status(); status != 0 {
+		return status
+	}
+	return http.StatusOK
+}
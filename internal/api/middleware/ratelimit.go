@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/auth"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/ratelimit"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tenant"
+)
+
+// RateLimits are the buckets RateLimit takes from. Disabled limits are
+// skipped.
+type RateLimits struct {
+	IP     ratelimit.Limit
+	Key    ratelimit.Limit
+	Tenant ratelimit.Limit
+	// TrustForwardedFor takes the client IP from the last
+	// X-Forwarded-For entry, for deployments behind a proxy that sets it.
+	TrustForwardedFor bool
+}
+
+// RateLimitIP takes one token per request from the client IP's bucket
+// and refuses the request with 429 and Retry-After when it is empty. It
+// runs before Authenticate, so a flood of bad keys is refused before any
+// key is looked up. The token is kept even if a later limit refuses the
+// request: it limits attempts. A nil store disables it.
+func RateLimitIP(store ratelimit.Store, limits RateLimits) func(http.Handler) http.Handler {
+	return limitRequests(store, func(r *http.Request) []rateBucket {
+		return []rateBucket{{"ip", "ip:" + clientIP(r, limits.TrustForwardedFor), limits.IP}}
+	})
+}
+
+// RateLimit takes one token per request from the API key's and the
+// tenant's bucket, in that order, and refuses the request with 429 and
+// Retry-After at the first empty one. Handlers whose requests carry
+// several events take the rest with TakeEvents. The bootstrap key, and
+// every request when authentication is off, has no key bucket. It must
+// run after Authenticate. A nil store disables it.
+func RateLimit(store ratelimit.Store, limits RateLimits) func(http.Handler) http.Handler {
+	return limitRequests(store, func(r *http.Request) []rateBucket {
+		return eventBuckets(r, limits)
+	})
+}
+
+// TakeEvents returns a function that takes n more tokens from the
+// request's key and tenant buckets, for handlers whose requests carry
+// several events. It reports false, and how long to wait, when a bucket
+// is empty. A nil store returns nil.
+func TakeEvents(store ratelimit.Store, limits RateLimits) func(r *http.Request, n int) (time.Duration, bool) {
+	if store == nil {
+		return nil
+	}
+	return func(r *http.Request, n int) (time.Duration, bool) {
+		name, res := takeAll(r, store, eventBuckets(r, limits), n)
+		if name == "" {
+			return 0, true
+		}
+		metrics.RateLimited.WithLabelValues(name).Inc()
+		return res.RetryAfter, false
+	}
+}
+
+type rateBucket struct {
+	name, key string
+	limit     ratelimit.Limit
+}
+
+// eventBuckets are the buckets charged per event: the API key's and the
+// tenant's.
+func eventBuckets(r *http.Request, limits RateLimits) []rateBucket {
+	var buckets []rateBucket
+	if key, ok := auth.FromContext(r.Context()); ok && key.ID != 0 {
+		buckets = append(buckets, rateBucket{"key", "key:" + strconv.FormatInt(key.ID, 10), limits.Key})
+	}
+	if id, ok := tenant.From(r.Context()); ok {
+		buckets = append(buckets, rateBucket{"tenant", "tenant:" + id, limits.Tenant})
+	}
+	return buckets
+}
+
+// limitRequests takes one token per request from each of the request's
+// buckets. X-RateLimit-* headers describe the bucket closest to running
+// out, across every limiter the request passed.
+func limitRequests(store ratelimit.Store, buckets func(r *http.Request) []rateBucket) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if store == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name, res := takeAll(r, store, buckets(r), 1)
+			if name != "" {
+				metrics.RateLimited.WithLabelValues(name).Inc()
+				setRateLimitHeaders(w, *res)
+				w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+				apierror.Write(w, r, http.StatusTooManyRequests, apierror.CodeRateLimited, "rate limit exceeded", nil)
+				return
+			}
+			if res != nil {
+				if left, err := strconv.Atoi(w.Header().Get("X-RateLimit-Remaining")); err != nil || res.Remaining < left {
+					setRateLimitHeaders(w, *res)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// takeAll takes n tokens from each enabled bucket in order, stopping at
+// the first that cannot spare them and putting back what the earlier
+// buckets gave, so a refused request costs nothing. It returns that
+// bucket's name and result, or "" and the result of the bucket closest to
+// running out (nil if no bucket is enabled). Buckets whose store fails
+// are skipped; an unavailable limit must not block ingestion.
+func takeAll(r *http.Request, store ratelimit.Store, buckets []rateBucket, n int) (string, *ratelimit.Result) {
+	var tightest *ratelimit.Result
+	var taken []rateBucket
+	for _, b := range buckets {
+		if !b.limit.Enabled() {
+			continue
+		}
+		res, err := store.Take(r.Context(), b.key, b.limit, n)
+		if err != nil {
+			continue
+		}
+		if !res.Allowed {
+			for _, t := range taken {
+				store.Return(r.Context(), t.key, t.limit, n)
+			}
+			return b.name, &res
+		}
+		taken = append(taken, b)
+		if tightest == nil || res.Remaining < tightest.Remaining {
+			tightest = &res
+		}
+	}
+	return "", tightest
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, res ratelimit.Result) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("X-RateLimit-Reset", ceilSeconds(res.Reset))
+}
+
+// ceilSeconds formats d as whole seconds, rounding up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// clientIP returns the address the request came from. Behind a trusted
+// proxy that is the last X-Forwarded-For entry; earlier entries are
+// supplied by the client and cannot be trusted.
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			parts := strings.Split(xff, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
+)
+
+// Recover turns a panicking handler into a logged 500 internal_error, so
+// one bad request does not drop the connection without a response. It runs
+// inside Logging and Metrics so both see the 500. http.ErrAbortHandler is
+// re-raised; it is how a handler asks the server to abort the response.
+func Recover(logger *logging.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				logger.WithContext(r.Context()).Error("handler panicked", map[string]any{
+					"method": r.Method,
+					"path":   r.URL.Path,
+					"error":  fmt.Sprint(rec),
+					"stack":  string(debug.Stack()),
+				})
+				apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "internal server error", nil)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
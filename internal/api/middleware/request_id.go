@@ -1,16 +1,12 @@
 package middleware
 
 import (
-	"context"
 	"net/http"
 
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
 	"github.com/google/uuid"
 )
 
-type ctxKey string
-
-const RequestIDKey ctxKey = "request_id"
-
 func RequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		reqID := r.Header.Get("X-Request-ID")
@@ -18,7 +14,7 @@ func RequestID(next http.Handler) http.Handler {
 			reqID = uuid.NewString()
 		}
 
-		ctx := context.WithValue(r.Context(), RequestIDKey, reqID)
+		ctx := logging.ContextWithRequestID(r.Context(), reqID)
 		w.Header().Set("X-Request-ID", reqID)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -2,55 +2,137 @@ package api
 
 import (
 	"net/http"
+	"slices"
 
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/apierror"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/handlers"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/api/middleware"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/auth"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/health"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/messaging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/ratelimit"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/replay"
 	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
 	"github.com/go-chi/chi/v5"
 )
 
-func NewRouter(producer *messaging.Producer, db *storage.DB) http.Handler {
+// RouterOptions configure access to the API.
+type RouterOptions struct {
+	// Auth checks API keys on /v1. Nil disables authentication and every
+	// request acts for the default tenant.
+	Auth *auth.Authenticator
+	// CORSOrigins are the browser origins allowed to call the API.
+	CORSOrigins []string
+	// RateLimiter holds the rate limit buckets: per client IP on all of
+	// /v1, per API key and tenant on ingestion. Nil disables rate limiting.
+	RateLimiter ratelimit.Store
+	RateLimits  middleware.RateLimits
+}
+
+func NewRouter(logger *logging.Logger, producer *messaging.Producer, db *storage.DB, ingest handlers.IngestOptions, opts RouterOptions) http.Handler {
 	r := chi.NewRouter()
 
 	// Global middleware
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Logging)
-	r.Use(corsMiddleware)
+	r.Use(middleware.Tracing)
+	r.Use(middleware.Logging(logger))
+	r.Use(middleware.Metrics)
+	r.Use(middleware.Recover(logger))
+	r.Use(corsMiddleware(opts.CORSOrigins))
+	r.NotFound(apierror.NotFound)
+	r.MethodNotAllowed(apierror.MethodNotAllowed)
 
 	r.Get("/healthz", health.Liveness)
 	r.Get("/readyz", health.Readiness)
+	r.Handle("/metrics", metrics.Handler())
 
 	qh := &handlers.QueryHandlers{DB: db}
+	replayer := replay.New(db, producer)
+	sh := &handlers.SchemaHandlers{DB: db, Registry: ingest.Schemas}
+	kh := &handlers.KeyHandlers{DB: db}
+	ingest.RateLimit = middleware.TakeEvents(opts.RateLimiter, opts.RateLimits)
 
 	r.Route("/v1", func(r chi.Router) {
+		r.Use(middleware.RateLimitIP(opts.RateLimiter, opts.RateLimits))
+		r.Use(middleware.Authenticate(opts.Auth))
+
 		// Write
-		r.Post("/events", handlers.HandleEvent(producer))
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeWrite))
+			r.Use(middleware.RateLimit(opts.RateLimiter, opts.RateLimits))
+			r.Post("/events", handlers.HandleEvent(producer, ingest))
+			r.Post("/events/batch", handlers.HandleEventBatch(producer, ingest))
+			r.Post("/events/stream", handlers.HandleEventStream(producer, ingest))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeRead))
+
+			// Read
+			r.Get("/events", qh.ListEvents)
+			r.Get("/events/{id}", qh.GetEvent)
+
+			// Analytics
+			r.Get("/analytics/summary", qh.GetSummary)
+			r.Get("/analytics/types", qh.GetTypeCounts)
+			r.Get("/analytics/timeline", qh.GetTimeline)
 
-		// Read
-		r.Get("/events", qh.ListEvents)
-		r.Get("/events/{id}", qh.GetEvent)
+			// Dead letters
+			r.Get("/dlq", qh.ListDLQ)
+			r.Get("/dlq/{id}", qh.GetDLQ)
 
-		// Analytics
-		r.Get("/analytics/summary", qh.GetSummary)
-		r.Get("/analytics/types", qh.GetTypeCounts)
-		r.Get("/analytics/timeline", qh.GetTimeline)
+			// Schema registry
+			r.Get("/schemas", sh.ListSchemas)
+			r.Get("/schemas/{event_type}", sh.ListTypeSchemas)
+			r.Get("/schemas/{event_type}/{version}", sh.GetSchema)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeAdmin))
+
+			// Dead-letter replay
+			r.Post("/dlq/replay", handlers.HandleDLQBulkReplay(replayer))
+			r.Post("/dlq/{id}/replay", handlers.HandleDLQReplay(replayer))
+
+			// API keys of the caller's tenant (every tenant's for operators)
+			r.Get("/admin/keys", kh.ListKeys)
+			r.Post("/admin/keys", kh.CreateKey)
+			r.Delete("/admin/keys/{id}", kh.RevokeKey)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeOperator))
+
+			// Schema changes validate every tenant's events
+			r.Post("/schemas/{event_type}", sh.CreateSchema)
+			r.Post("/schemas/{event_type}/deactivate", sh.DeactivateSchema)
+			r.Delete("/schemas/{event_type}/{version}", sh.DeleteSchema)
+			r.Post("/schemas/{event_type}/{version}/activate", sh.ActivateSchema)
+		})
 	})
 
 	return r
 }
 
-// corsMiddleware allows the frontend dev server to reach the API.
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Request-ID")
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// corsMiddleware lets the listed browser origins, such as the frontend
+// dev server, call the API.
+func corsMiddleware(origins []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(origins, origin) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID, traceparent, tracestate")
+				w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset")
+			}
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,196 @@
+// Package auth resolves API keys to the tenant and scopes they grant.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tenant"
+)
+
+// Scope is a permission granted by a key.
+type Scope string
+
+const (
+	ScopeWrite Scope = "write" // ingest events (producers)
+	ScopeRead  Scope = "read"  // query events, analytics, DLQ and schemas (dashboard)
+	ScopeAdmin Scope = "admin" // everything in its tenant, plus key management and DLQ replay
+	// ScopeOperator runs the platform: keys of every tenant and changes to
+	// the schema registry, which validates every tenant's events. Only the
+	// bootstrap key carries it.
+	ScopeOperator Scope = "operator"
+)
+
+// ValidScope reports whether s names a scope a stored key may carry.
+// Operator is not one of them.
+func ValidScope(s string) bool {
+	switch Scope(s) {
+	case ScopeWrite, ScopeRead, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// ErrInvalidKey is returned for unknown or revoked keys.
+var ErrInvalidKey = errors.New("invalid api key")
+
+// keyPrefix marks platform keys so they are easy to spot in leaks.
+const keyPrefix = "eak_"
+
+// Generate returns a new random key together with the hash and display
+// prefix to store. The key itself must only be shown to its owner.
+func Generate() (key, hash, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("generate api key: %w", err)
+	}
+	key = keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, Hash(key), key[:len(keyPrefix)+8], nil
+}
+
+// Hash returns the hex SHA-256 of key. Keys carry 256 bits of entropy, so
+// a fast hash is enough to make a leaked table useless.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Key is an authenticated caller.
+type Key struct {
+	ID       int64 // 0 for the bootstrap key
+	TenantID string
+	Scopes   []string
+}
+
+// Has reports whether the key grants scope. Admin grants every scope but
+// operator.
+func (k *Key) Has(scope Scope) bool {
+	if slices.Contains(k.Scopes, string(scope)) {
+		return true
+	}
+	return scope != ScopeOperator && slices.Contains(k.Scopes, string(ScopeAdmin))
+}
+
+// Store looks up stored keys by hash.
+type Store interface {
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*storage.APIKey, error)
+}
+
+type cacheEntry struct {
+	key     *Key
+	expires time.Time
+}
+
+const (
+	// missTTL is how long a key that failed lookup is refused without
+	// asking the database again. It is short, so a key that was just
+	// created is not held up for long.
+	missTTL = 5 * time.Second
+	// maxMisses bounds the remembered failed keys, since a flood of random
+	// keys would otherwise grow them without limit.
+	maxMisses = 10000
+)
+
+// Authenticator resolves presented keys. Lookups are cached for the
+// configured TTL so ingestion does not pay a database round trip per
+// request; a revoked key keeps working until its entry expires. Failed
+// lookups are remembered for missTTL so bad keys cannot flood the
+// database either.
+type Authenticator struct {
+	store         Store
+	ttl           time.Duration
+	bootstrapHash string
+	maxMisses     int
+
+	mu     sync.Mutex
+	cache  map[string]cacheEntry
+	misses map[string]time.Time // failed key hashes and when they expire
+}
+
+// NewAuthenticator returns an Authenticator backed by store. A non-empty
+// bootstrapKey is accepted as an admin and operator key for the default
+// tenant, so the first real keys can be created on a fresh deployment.
+func NewAuthenticator(store Store, ttl time.Duration, bootstrapKey string) *Authenticator {
+	a := &Authenticator{
+		store:     store,
+		ttl:       ttl,
+		maxMisses: maxMisses,
+		cache:     make(map[string]cacheEntry),
+		misses:    make(map[string]time.Time),
+	}
+	if bootstrapKey != "" {
+		a.bootstrapHash = Hash(bootstrapKey)
+	}
+	return a
+}
+
+// Authenticate returns the caller presenting key, or ErrInvalidKey.
+func (a *Authenticator) Authenticate(ctx context.Context, key string) (*Key, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+	hash := Hash(key)
+	if a.bootstrapHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(a.bootstrapHash)) == 1 {
+		return &Key{TenantID: tenant.Default, Scopes: []string{string(ScopeAdmin), string(ScopeOperator)}}, nil
+	}
+
+	now := time.Now()
+	a.mu.Lock()
+	entry, ok := a.cache[hash]
+	missExpires, missed := a.misses[hash]
+	a.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.key, nil
+	}
+	if missed && now.Before(missExpires) {
+		return nil, ErrInvalidKey
+	}
+
+	stored, err := a.store.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		a.mu.Lock()
+		delete(a.cache, hash)
+		if !missed && len(a.misses) >= a.maxMisses {
+			for h := range a.misses { // evict an arbitrary entry
+				delete(a.misses, h)
+				break
+			}
+		}
+		a.misses[hash] = now.Add(missTTL)
+		a.mu.Unlock()
+		return nil, ErrInvalidKey
+	}
+
+	k := &Key{ID: stored.ID, TenantID: stored.TenantID, Scopes: stored.Scopes}
+	a.mu.Lock()
+	a.cache[hash] = cacheEntry{key: k, expires: now.Add(a.ttl)}
+	delete(a.misses, hash)
+	a.mu.Unlock()
+	return k, nil
+}
+
+type ctxKey struct{}
+
+// WithKey returns ctx carrying the authenticated key.
+func WithKey(ctx context.Context, k *Key) context.Context {
+	return context.WithValue(ctx, ctxKey{}, k)
+}
+
+// FromContext returns the key ctx was authenticated with, if any.
+func FromContext(ctx context.Context) (*Key, bool) {
+	k, ok := ctx.Value(ctxKey{}).(*Key)
+	return k, ok
+}
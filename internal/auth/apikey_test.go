@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tenant"
+)
+
+type fakeStore struct {
+	keys  map[string]*storage.APIKey // by hash
+	calls int
+	err   error
+}
+
+func (s *fakeStore) GetAPIKeyByHash(_ context.Context, hash string) (*storage.APIKey, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.keys[hash], nil
+}
+
+func newStoreWithKey(t *testing.T, scopes ...string) (*fakeStore, string) {
+	t.Helper()
+	key, hash, prefix, err := Generate()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if !strings.HasPrefix(key, prefix) {
+		t.Fatalf("prefix %q is not a prefix of the key", prefix)
+	}
+	return &fakeStore{keys: map[string]*storage.APIKey{
+		hash: {ID: 7, TenantID: "acme", Scopes: scopes},
+	}}, key
+}
+
+func TestAuthenticate_ValidKey(t *testing.T) {
+	store, key := newStoreWithKey(t, "write")
+	a := NewAuthenticator(store, time.Minute, "")
+
+	k, err := a.Authenticate(context.Background(), key)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if k.TenantID != "acme" || !k.Has(ScopeWrite) || k.Has(ScopeRead) {
+		t.Errorf("unexpected key %+v", k)
+	}
+}
+
+func TestAuthenticate_UnknownKey(t *testing.T) {
+	store, _ := newStoreWithKey(t, "read")
+	a := NewAuthenticator(store, time.Minute, "")
+
+	for _, key := range []string{"", "eak_not-a-real-key"} {
+		if _, err := a.Authenticate(context.Background(), key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("key %q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
+
+func TestAuthenticate_CachesLookups(t *testing.T) {
+	store, key := newStoreWithKey(t, "read")
+	a := NewAuthenticator(store, time.Minute, "")
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Authenticate(context.Background(), key); err != nil {
+			t.Fatalf("authenticate: %v", err)
+		}
+	}
+	if store.calls != 1 {
+		t.Errorf("expected 1 store lookup within TTL, got %d", store.calls)
+	}
+}
+
+func TestAuthenticate_RemembersUnknownKeys(t *testing.T) {
+	store, _ := newStoreWithKey(t, "read")
+	a := NewAuthenticator(store, time.Minute, "")
+	const key = "eak_not-a-real-key"
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Authenticate(context.Background(), key); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("expected ErrInvalidKey, got %v", err)
+		}
+	}
+	if store.calls != 1 {
+		t.Errorf("expected 1 store lookup within the miss TTL, got %d", store.calls)
+	}
+
+	// Once the miss expires the key is looked up again.
+	for h := range a.misses {
+		a.misses[h] = time.Now().Add(-time.Second)
+	}
+	if _, err := a.Authenticate(context.Background(), key); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+	if store.calls != 2 {
+		t.Errorf("expected a second store lookup after the miss expired, got %d", store.calls)
+	}
+}
+
+func TestAuthenticate_BoundsUnknownKeys(t *testing.T) {
+	store, _ := newStoreWithKey(t, "read")
+	a := NewAuthenticator(store, time.Minute, "")
+	a.maxMisses = 2
+
+	for _, key := range []string{"eak_a", "eak_b", "eak_c", "eak_d"} {
+		if _, err := a.Authenticate(context.Background(), key); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("key %q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+	if len(a.misses) != 2 {
+		t.Errorf("expected 2 remembered misses, got %d", len(a.misses))
+	}
+}
+
+func TestAuthenticate_StoreError(t *testing.T) {
+	store, key := newStoreWithKey(t, "read")
+	store.err = errors.New("connection refused")
+	a := NewAuthenticator(store, time.Minute, "")
+
+	_, err := a.Authenticate(context.Background(), key)
+	if err == nil || errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected the store error, got %v", err)
+	}
+}
+
+func TestAuthenticate_BootstrapKey(t *testing.T) {
+	a := NewAuthenticator(&fakeStore{}, time.Minute, "let-me-in")
+
+	k, err := a.Authenticate(context.Background(), "let-me-in")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if k.TenantID != tenant.Default || !k.Has(ScopeAdmin) || !k.Has(ScopeRead) || !k.Has(ScopeOperator) {
+		t.Errorf("expected default-tenant admin and operator, got %+v", k)
+	}
+}
+
+func TestKey_AdminIsNotOperator(t *testing.T) {
+	k := &Key{TenantID: "acme", Scopes: []string{string(ScopeAdmin)}}
+	if !k.Has(ScopeWrite) || !k.Has(ScopeRead) {
+		t.Error("expected admin to grant write and read")
+	}
+	if k.Has(ScopeOperator) {
+		t.Error("expected a tenant admin not to be an operator")
+	}
+	if ValidScope(string(ScopeOperator)) {
+		t.Error("expected operator not to be grantable to stored keys")
+	}
+}
@@ -5,34 +5,155 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
 	ServiceName string
 	Port        string
+	AdminPort   string // Consumer /healthz, /readyz and /metrics ("" disables)
 	LogLevel    string
 
-	KafkaBrokers  []string
-	KafkaTopic    string
-	KafkaGroupID  string
-	KafkaDLQTopic string // Dead-letter topic for failed messages
+	// TraceExporter is where spans go: "none", "stdout" or "otlp".
+	TraceExporter string
+
+	KafkaBrokers    []string
+	KafkaTopic      string
+	KafkaGroupID    string
+	KafkaDLQTopic   string // Dead-letter topic for failed messages
+	KafkaDLQGroupID string // Consumer group that persists DLQ envelopes
 
 	DatabaseDSN string
 
+	// API access
+	AuthEnabled  bool          // Require API keys on /v1; off acts as the default tenant
+	AdminAPIKey  string        // Bootstrap admin key for the default tenant ("" disables)
+	AuthCacheTTL time.Duration // How long a key lookup is reused
+	CORSOrigins  []string      // Browser origins allowed by CORS
+
+	// Daily event quotas per tenant (0 is unlimited)
+	TenantDailyQuota     int64         // Quota of tenants without an override
+	TenantQuotas         string        // Overrides, e.g. "acme=1000000,beta=0"
+	QuotaRefreshInterval time.Duration // How often usage is recounted from Postgres
+
+	// Ingestion rate limits in requests per second (0 disables) and burst
+	// sizes (0 allows one second's worth)
+	RateLimitStore       string // "memory" (per replica) | "postgres" (shared)
+	RateLimitIPRPS       float64
+	RateLimitIPBurst     int
+	RateLimitKeyRPS      float64
+	RateLimitKeyBurst    int
+	RateLimitTenantRPS   float64
+	RateLimitTenantBurst int
+	TrustForwardedFor    bool // Rate limit on X-Forwarded-For instead of the peer address
+
+	// Ingestion publish durability
+	PublishMode       string        // "async" | "sync" | "spool"
+	PublishTimeout    time.Duration // How long a request waits for the broker ack
+	PublishRetryAfter time.Duration // Retry-After hint when a publish fails
+	MaxBatchEvents    int           // Max events per POST /v1/events/batch
+	StreamBatchEvents int           // Events per publish in POST /v1/events/stream
+
+	// Request decoding limits
+	MaxBodyBytes      int64 // Single event body, and each streamed event
+	MaxBatchBodyBytes int64 // POST /v1/events/batch body
+	MaxPayloadBytes   int   // An event's payload
+	MaxJSONDepth      int   // Nesting depth of an event
+	StrictJSON        bool  // Reject unknown event fields
+
+	SchemaRefreshInterval time.Duration // How often active schemas are reloaded
+
+	// Accepted window for client-supplied occurred_at timestamps
+	EventMaxFutureSkew time.Duration // How far ahead of the server clock
+	EventMaxLateness   time.Duration // How far behind; 0 accepts any age
+
+	GenerateEventIDs bool // Mint a UUIDv7 when a request omits event_id
+
+	// Local spool for events Kafka did not accept ("" disables it)
+	SpoolDir           string
+	SpoolMaxBytes      int64
+	SpoolSegmentBytes  int64
+	SpoolDrainInterval time.Duration
+
 	// Retry discipline
 	MaxRetries int
+	// RetryTiers lists retry topic delays, e.g. "1s,30s,5m". Empty keeps
+	// retries in place with backoff.
+	RetryTiers string
+
+	// Consumer batching: up to ConsumerBatchSize messages, waiting at most
+	// ConsumerBatchWait after the first, are inserted with one statement.
+	// A size of 1 disables batching.
+	ConsumerBatchSize int
+	ConsumerBatchWait time.Duration
+	// ConsumerConcurrency is the number of ordered processing lanes used
+	// when batching is off. 1 processes messages serially.
+	ConsumerConcurrency int
+	// ConsumerMaxLag marks the consumer not-ready once it falls this many
+	// messages behind (0 disables the check).
+	ConsumerMaxLag int
+
+	// ShutdownTimeout bounds the drain after SIGTERM: in-flight work that
+	// has not finished by then is abandoned uncommitted.
+	ShutdownTimeout time.Duration
+	// ShutdownReadyDelay is how long the API reports not-ready before it
+	// stops accepting connections, giving load balancers time to notice.
+	ShutdownReadyDelay time.Duration
 }
 
 func Load() *Config {
 	return &Config{
-		ServiceName:   getEnv("SERVICE_NAME", "ingestion-api"),
-		Port:          getEnv("PORT", "8080"),
-		LogLevel:      getEnv("LOG_LEVEL", "info"),
-		KafkaBrokers:  strings.Split(getEnv("KAFKA_BROKERS", "localhost:9093"), ","),
-		KafkaTopic:    getEnv("KAFKA_TOPIC", "events"),
-		KafkaGroupID:  getEnv("KAFKA_GROUP_ID", "event-consumer-group"),
-		KafkaDLQTopic: getEnv("KAFKA_DLQ_TOPIC", "events.dlq"),
-		MaxRetries:    getEnvInt("MAX_RETRIES", 5),
+		ServiceName:           getEnv("SERVICE_NAME", "ingestion-api"),
+		Port:                  getEnv("PORT", "8080"),
+		AdminPort:             getEnv("ADMIN_PORT", "8081"),
+		LogLevel:              getEnv("LOG_LEVEL", "info"),
+		TraceExporter:         getEnv("TRACE_EXPORTER", "none"),
+		KafkaBrokers:          strings.Split(getEnv("KAFKA_BROKERS", "localhost:9093"), ","),
+		KafkaTopic:            getEnv("KAFKA_TOPIC", "events"),
+		KafkaGroupID:          getEnv("KAFKA_GROUP_ID", "event-consumer-group"),
+		KafkaDLQTopic:         getEnv("KAFKA_DLQ_TOPIC", "events.dlq"),
+		KafkaDLQGroupID:       getEnv("KAFKA_DLQ_GROUP_ID", "dlq-consumer-group"),
+		MaxRetries:            getEnvInt("MAX_RETRIES", 5),
+		PublishMode:           getEnv("PUBLISH_MODE", "async"),
+		PublishTimeout:        getEnvDuration("PUBLISH_TIMEOUT", 5*time.Second),
+		PublishRetryAfter:     getEnvDuration("PUBLISH_RETRY_AFTER", 5*time.Second),
+		MaxBatchEvents:        getEnvInt("MAX_BATCH_EVENTS", 500),
+		StreamBatchEvents:     getEnvInt("STREAM_BATCH_EVENTS", 500),
+		MaxBodyBytes:          int64(getEnvInt("MAX_BODY_BYTES", 1<<20)),
+		MaxBatchBodyBytes:     int64(getEnvInt("MAX_BATCH_BODY_BYTES", 16<<20)),
+		MaxPayloadBytes:       getEnvInt("MAX_PAYLOAD_BYTES", 64<<10),
+		MaxJSONDepth:          getEnvInt("MAX_JSON_DEPTH", 32),
+		StrictJSON:            getEnvBool("STRICT_JSON", false),
+		EventMaxFutureSkew:    getEnvDuration("EVENT_MAX_FUTURE_SKEW", 5*time.Minute),
+		EventMaxLateness:      getEnvDuration("EVENT_MAX_LATENESS", 0),
+		SpoolDir:              getEnv("SPOOL_DIR", ""),
+		SpoolMaxBytes:         int64(getEnvInt("SPOOL_MAX_BYTES", 1<<30)),
+		SpoolSegmentBytes:     int64(getEnvInt("SPOOL_SEGMENT_BYTES", 64<<20)),
+		SpoolDrainInterval:    getEnvDuration("SPOOL_DRAIN_INTERVAL", time.Second),
+		SchemaRefreshInterval: getEnvDuration("SCHEMA_REFRESH_INTERVAL", 30*time.Second),
+		GenerateEventIDs:      getEnvBool("GENERATE_EVENT_IDS", false),
+		RetryTiers:            getEnv("RETRY_TIERS", ""),
+		ConsumerBatchSize:     getEnvInt("CONSUMER_BATCH_SIZE", 1),
+		ConsumerBatchWait:     getEnvDuration("CONSUMER_BATCH_WAIT", 100*time.Millisecond),
+		ConsumerConcurrency:   getEnvInt("CONSUMER_CONCURRENCY", 1),
+		ConsumerMaxLag:        getEnvInt("CONSUMER_MAX_LAG", 100000),
+		ShutdownTimeout:       getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		ShutdownReadyDelay:    getEnvDuration("SHUTDOWN_READY_DELAY", 5*time.Second),
+		AuthEnabled:           getEnvBool("AUTH_ENABLED", true),
+		AdminAPIKey:           getEnv("ADMIN_API_KEY", ""),
+		AuthCacheTTL:          getEnvDuration("AUTH_CACHE_TTL", 30*time.Second),
+		CORSOrigins:           strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000"), ","),
+		TenantDailyQuota:      int64(getEnvInt("TENANT_DAILY_QUOTA", 0)),
+		TenantQuotas:          getEnv("TENANT_QUOTAS", ""),
+		QuotaRefreshInterval:  getEnvDuration("QUOTA_REFRESH_INTERVAL", 30*time.Second),
+		RateLimitStore:        getEnv("RATE_LIMIT_STORE", "memory"),
+		RateLimitIPRPS:        getEnvFloat("RATE_LIMIT_IP_RPS", 0),
+		RateLimitIPBurst:      getEnvInt("RATE_LIMIT_IP_BURST", 0),
+		RateLimitKeyRPS:       getEnvFloat("RATE_LIMIT_KEY_RPS", 0),
+		RateLimitKeyBurst:     getEnvInt("RATE_LIMIT_KEY_BURST", 0),
+		RateLimitTenantRPS:    getEnvFloat("RATE_LIMIT_TENANT_RPS", 0),
+		RateLimitTenantBurst:  getEnvInt("RATE_LIMIT_TENANT_BURST", 0),
+		TrustForwardedFor:     getEnvBool("TRUST_FORWARDED_FOR", false),
 		DatabaseDSN: fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
 			getEnv("DB_USER", "events_user"),
 			getEnv("DB_PASSWORD", "events_password"),
@@ -61,3 +182,39 @@ func getEnvInt(key string, fallback int) int {
 	}
 	return n
 }
+
+func getEnvFloat(key string, fallback float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fallback
+	}
+	return f
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
@@ -1,14 +1,184 @@
 package health
 
-import "net/http"
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Component statuses reported by /readyz.
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded" // a non-critical check failed
+	StatusDraining = "draining"
+)
+
+// CheckFunc reports a dependency's health; nil means healthy.
+type CheckFunc func(ctx context.Context) error
+
+// CheckOptions tune a registered check.
+type CheckOptions struct {
+	Timeout  time.Duration // Per-run deadline (default 2s)
+	CacheTTL time.Duration // How long a result is reused (default 2s)
+	// NonCritical checks are reported but do not make the service
+	// not-ready, e.g. Kafka when a spool can absorb an outage.
+	NonCritical bool
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Critical  bool   `json:"critical"`
+}
+
+// Report is the body served by /readyz.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+	opts CheckOptions
+
+	mu        sync.Mutex // serialises runs so concurrent probes share one
+	last      Result
+	checkedAt time.Time
+}
+
+// Registry runs the readiness checks a service registers at startup.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []*check
+	draining atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a named check.
+func (r *Registry) Register(name string, fn CheckFunc, opts CheckOptions) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 2 * time.Second
+	}
+	r.mu.Lock()
+	r.checks = append(r.checks, &check{name: name, fn: fn, opts: opts})
+	r.mu.Unlock()
+}
+
+// StartDraining makes the registry report not-ready for the rest of the
+// process lifetime.
+func (r *Registry) StartDraining() {
+	r.draining.Store(true)
+}
+
+// Check runs every check concurrently, reusing results younger than
+// their CacheTTL.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.checks
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results}
+	for _, res := range results {
+		switch {
+		case res.Status == StatusUp:
+		case res.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	if r.draining.Load() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func (c *check) run(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.opts.CacheTTL {
+		return c.last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.fn(ctx)
+	res := Result{
+		Name:      c.name,
+		Status:    StatusUp,
+		LatencyMS: time.Since(start).Milliseconds(),
+		Critical:  !c.opts.NonCritical,
+	}
+	if err != nil {
+		res.Status, res.Error = StatusDown, err.Error()
+	}
+
+	c.last, c.checkedAt = res, time.Now()
+	return res
+}
+
+// ServeHTTP serves the readiness report: 200 when up or degraded, 503
+// when a critical check failed or the service is draining.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	report := r.Check(req.Context())
+
+	status := http.StatusOK
+	if report.Status == StatusDown || report.Status == StatusDraining {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Default is the process-wide registry served by Readiness.
+var Default = NewRegistry()
+
+// Register adds a check to the Default registry.
+func Register(name string, fn CheckFunc, opts CheckOptions) {
+	Default.Register(name, fn, opts)
+}
+
+// StartDraining makes Readiness report not-ready for the rest of the
+// process lifetime.
+func StartDraining() {
+	Default.StartDraining()
+}
 
 func Liveness(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("ok"))
 }
 
-func Readiness(w http.ResponseWriter, _ *http.Request) {
-	// Kafka / DB checks will come later
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("ready"))
+// Readiness serves the Default registry's report.
+func Readiness(w http.ResponseWriter, r *http.Request) {
+	Default.ServeHTTP(w, r)
 }
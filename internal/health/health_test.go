@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistry_AllUp(t *testing.T) {
+	r := NewRegistry()
+	r.Register("postgres", func(context.Context) error { return nil }, CheckOptions{})
+	r.Register("kafka", func(context.Context) error { return nil }, CheckOptions{})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.Status != StatusUp || len(report.Checks) != 2 {
+		t.Errorf("expected up with 2 checks, got %+v", report)
+	}
+}
+
+func TestRegistry_CriticalFailure(t *testing.T) {
+	r := NewRegistry()
+	r.Register("postgres", func(context.Context) error { return errors.New("connection refused") }, CheckOptions{})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	report := r.Check(context.Background())
+	if report.Checks[0].Error != "connection refused" {
+		t.Errorf("expected error in report, got %+v", report.Checks[0])
+	}
+}
+
+func TestRegistry_NonCriticalFailureIsDegraded(t *testing.T) {
+	r := NewRegistry()
+	r.Register("postgres", func(context.Context) error { return nil }, CheckOptions{})
+	r.Register("kafka", func(context.Context) error { return errors.New("no brokers") }, CheckOptions{NonCritical: true})
+
+	report := r.Check(context.Background())
+	if report.Status != StatusDegraded {
+		t.Errorf("expected degraded, got %q", report.Status)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 while degraded, got %d", rec.Code)
+	}
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	r := NewRegistry()
+	r.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, CheckOptions{Timeout: 10 * time.Millisecond})
+
+	start := time.Now()
+	report := r.Check(context.Background())
+	if time.Since(start) > time.Second {
+		t.Fatal("check was not bounded by its timeout")
+	}
+	if report.Status != StatusDown {
+		t.Errorf("expected down after timeout, got %q", report.Status)
+	}
+}
+
+func TestRegistry_CachesResults(t *testing.T) {
+	var calls atomic.Int32
+	r := NewRegistry()
+	r.Register("postgres", func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}, CheckOptions{CacheTTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		r.Check(context.Background())
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 call within TTL, got %d", n)
+	}
+}
+
+func TestRegistry_Draining(t *testing.T) {
+	r := NewRegistry()
+	r.Register("postgres", func(context.Context) error { return nil }, CheckOptions{})
+	r.StartDraining()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while draining, got %d", rec.Code)
+	}
+}
@@ -1,33 +1,142 @@
+// Package logging writes leveled JSON logs, one object per line, with the
+// service name and any request or trace ID found in the context.
 package logging
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"sort"
+	"strings"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tracing"
+)
+
+// Log levels accepted by LOG_LEVEL.
+const (
+	LevelDebug = slog.LevelDebug
+	LevelInfo  = slog.LevelInfo
+	LevelWarn  = slog.LevelWarn
+	LevelError = slog.LevelError
 )
 
+// Logger is safe for concurrent use. Child loggers from With and
+// WithContext share its output and level.
 type Logger struct {
-	*log.Logger
-	Service string
+	slog *slog.Logger
+}
+
+// New returns a logger for service writing to stdout. level is a LOG_LEVEL
+// value; an unknown one falls back to info and is reported as a warning.
+func New(service, level string) *Logger {
+	lvl, err := ParseLevel(level)
+	l := newLogger(os.Stdout, service, lvl)
+	if err != nil {
+		l.Warn("unknown LOG_LEVEL, using info", map[string]any{"log_level": level})
+	}
+	return l
+}
+
+func newLogger(w io.Writer, service string, level slog.Level) *Logger {
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.MessageKey {
+				a.Key = "message"
+			}
+			return a
+		},
+	})
+	return &Logger{slog: slog.New(h).With("service", service)}
 }
 
-func New(service string) *Logger {
-	return &Logger{
-		Logger: log.New(os.Stdout, "", 0),
-		Service: service,
+// ParseLevel parses "debug", "info", "warn" or "error", ignoring case.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
 	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+func (l *Logger) Debug(msg string, fields map[string]any) {
+	l.log(LevelDebug, msg, fields)
 }
 
 func (l *Logger) Info(msg string, fields map[string]any) {
-	l.log("INFO", msg, fields)
+	l.log(LevelInfo, msg, fields)
+}
+
+func (l *Logger) Warn(msg string, fields map[string]any) {
+	l.log(LevelWarn, msg, fields)
 }
 
 func (l *Logger) Error(msg string, fields map[string]any) {
-	l.log("ERROR", msg, fields)
+	l.log(LevelError, msg, fields)
+}
+
+// With returns a child logger that adds fields to every entry.
+func (l *Logger) With(fields map[string]any) *Logger {
+	return &Logger{slog: slog.New(l.slog.Handler().WithAttrs(attrs(fields)))}
+}
+
+// WithContext returns a child logger that adds the request and trace IDs
+// carried by ctx, if any.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	fields := map[string]any{}
+	if id := RequestID(ctx); id != "" {
+		fields["request_id"] = id
+	}
+	if id := tracing.TraceID(ctx); id != "" {
+		fields["trace_id"] = id
+	}
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields)
+}
+
+// log does not modify fields, so callers may reuse the map.
+func (l *Logger) log(level slog.Level, msg string, fields map[string]any) {
+	if !l.slog.Enabled(context.Background(), level) {
+		return
+	}
+	l.slog.LogAttrs(context.Background(), level, msg, attrs(fields)...)
+}
+
+// attrs converts fields to attributes in key order so output is stable.
+func attrs(fields map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]slog.Attr, len(keys))
+	for i, k := range keys {
+		out[i] = slog.Any(k, fields[k])
+	}
+	return out
+}
+
+type ctxKey struct{}
+
+// ContextWithRequestID returns ctx carrying the request ID that
+// WithContext adds to log entries.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
 }
 
-func (l *Logger) log(level, msg string, fields map[string]any) {
-	fields["level"] = level
-	fields["service"] = l.Service
-	fields["message"] = msg
-	l.Logger.Println(fields)
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
 }
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("line is not JSON: %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLogger_JSONFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLogger(&buf, "ingestion-api", LevelInfo)
+
+	fields := map[string]any{"event_id": "e1", "attempt": 2}
+	l.Error("db insert failed", fields)
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e["level"] != "ERROR" || e["message"] != "db insert failed" || e["service"] != "ingestion-api" {
+		t.Errorf("unexpected standard fields: %v", e)
+	}
+	if e["event_id"] != "e1" || e["attempt"] != float64(2) {
+		t.Errorf("unexpected caller fields: %v", e)
+	}
+	if len(fields) != 2 {
+		t.Errorf("caller's map was modified: %v", fields)
+	}
+}
+
+func TestLogger_LevelFilter(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLogger(&buf, "svc", LevelWarn)
+
+	l.Debug("debug", nil)
+	l.Info("info", nil)
+	l.Warn("warn", nil)
+	l.Error("error", nil)
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 || entries[0]["message"] != "warn" || entries[1]["message"] != "error" {
+		t.Errorf("expected warn and error only, got %v", entries)
+	}
+}
+
+func TestLogger_WithAndContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLogger(&buf, "svc", LevelInfo).With(map[string]any{"partition": 3})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(
+		ContextWithRequestID(context.Background(), "req-1"),
+		trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}),
+	)
+	l.WithContext(ctx).Info("event persisted", nil)
+	l.Info("no context", nil)
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	e := entries[0]
+	if e["partition"] != float64(3) || e["request_id"] != "req-1" || e["trace_id"] != traceID.String() {
+		t.Errorf("expected inherited and context fields, got %v", e)
+	}
+	if _, ok := entries[1]["request_id"]; ok {
+		t.Errorf("parent logger picked up context fields: %v", entries[1])
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]string{"debug": "DEBUG", "INFO": "INFO", "": "INFO", "warning": "WARN", "error": "ERROR"} {
+		got, err := ParseLevel(in)
+		if err != nil || got.String() != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %s", in, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
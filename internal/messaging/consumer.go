@@ -3,8 +3,10 @@ package messaging
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
 	"github.com/segmentio/kafka-go"
 )
 
@@ -36,9 +38,40 @@ func (c *Consumer) FetchMessage(ctx context.Context) (kafka.Message, error) {
 	if err != nil {
 		return kafka.Message{}, fmt.Errorf("fetch message: %w", err)
 	}
+	observeLag(msg)
 	return msg, nil
 }
 
+func observeLag(msg kafka.Message) {
+	metrics.ConsumerLag.
+		WithLabelValues(msg.Topic, strconv.Itoa(msg.Partition)).
+		Set(float64(msg.HighWaterMark - msg.Offset - 1))
+}
+
+// FetchBatch blocks for the first message, then keeps fetching until it
+// holds max messages or linger has passed since the first arrived. Only
+// an error fetching the first message is returned; later errors,
+// including ctx cancellation, end the batch early.
+func (c *Consumer) FetchBatch(ctx context.Context, max int, linger time.Duration) ([]kafka.Message, error) {
+	first, err := c.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch := []kafka.Message{first}
+
+	lingerCtx, cancel := context.WithTimeout(ctx, linger)
+	defer cancel()
+	for len(batch) < max {
+		msg, err := c.reader.FetchMessage(lingerCtx)
+		if err != nil {
+			break
+		}
+		observeLag(msg)
+		batch = append(batch, msg)
+	}
+	return batch, nil
+}
+
 // CommitMessage explicitly commits the offset for the given message.
 // Call this only after the message has been successfully persisted.
 func (c *Consumer) CommitMessage(ctx context.Context, msg kafka.Message) error {
@@ -48,6 +81,20 @@ func (c *Consumer) CommitMessage(ctx context.Context, msg kafka.Message) error {
 	return nil
 }
 
+// CommitMessages commits the offsets of a whole batch in one request.
+func (c *Consumer) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if err := c.reader.CommitMessages(ctx, msgs...); err != nil {
+		return fmt.Errorf("commit offsets: %w", err)
+	}
+	return nil
+}
+
+// Lag returns how many messages the reader is behind the partition high
+// watermark, as of its last fetch.
+func (c *Consumer) Lag() int64 {
+	return c.reader.Stats().Lag
+}
+
 func (c *Consumer) Close() error {
 	return c.reader.Close()
 }
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // DLQMessage wraps the original message with failure metadata so
@@ -23,6 +25,10 @@ type DLQMessage struct {
 	FailedAt          time.Time       `json:"failed_at"`
 }
 
+// ReplayCountHeader is set on messages re-published from the DLQ so the
+// consumer and operators can tell replays apart from first deliveries.
+const ReplayCountHeader = "replay-count"
+
 // DLQProducer writes failed messages to a dead-letter topic.
 type DLQProducer struct {
 	writer *kafka.Writer
@@ -41,8 +47,13 @@ func NewDLQProducer(brokers []string, dlqTopic string) *DLQProducer {
 	return &DLQProducer{writer: w, topic: dlqTopic}
 }
 
-// Send routes a failed message to the DLQ with full failure context.
-func (d *DLQProducer) Send(ctx context.Context, original kafka.Message, reason error, kind ErrorKind, retries int) error {
+// Send routes a failed message to the DLQ with full failure context. The
+// DLQ message carries the trace context of ctx.
+func (d *DLQProducer) Send(ctx context.Context, original kafka.Message, reason error, kind ErrorKind, retries int) (err error) {
+	ctx, span := startPublishSpan(ctx, d.topic, 1)
+	span.SetAttributes(attribute.String("error.type", kind.String()))
+	defer func() { tracing.End(span, err) }()
+
 	envelope := DLQMessage{
 		OriginalTopic:     original.Topic,
 		OriginalPartition: original.Partition,
@@ -68,6 +79,7 @@ func (d *DLQProducer) Send(ctx context.Context, original kafka.Message, reason e
 			{Key: "original-topic", Value: []byte(original.Topic)},
 		},
 	}
+	InjectTrace(ctx, &msg)
 
 	if err := d.writer.WriteMessages(ctx, msg); err != nil {
 		return fmt.Errorf("write to DLQ topic %s: %w", d.topic, err)
@@ -0,0 +1,86 @@
+package messaging
+
+import (
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ──────────────────────────────────────────────────────────────
+// Out-of-order completion → in-order commits
+// ──────────────────────────────────────────────────────────────
+
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// partitionOffsets holds the in-flight messages of one partition in fetch
+// order, plus the offsets that finished ahead of the oldest one.
+type partitionOffsets struct {
+	pending []kafka.Message
+	done    map[int64]bool
+}
+
+// OffsetTracker lets messages of a partition complete out of order while
+// offsets are only committed up to the lowest contiguous processed offset,
+// so a crash never skips a message that was still in flight.
+type OffsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition]*partitionOffsets
+}
+
+func NewOffsetTracker() *OffsetTracker {
+	return &OffsetTracker{partitions: make(map[topicPartition]*partitionOffsets)}
+}
+
+// Track registers a fetched message. Messages of a partition must be
+// tracked in the order they were fetched.
+func (t *OffsetTracker) Track(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tp := topicPartition{msg.Topic, msg.Partition}
+	p, ok := t.partitions[tp]
+	if !ok {
+		p = &partitionOffsets{done: make(map[int64]bool)}
+		t.partitions[tp] = p
+	}
+	p.pending = append(p.pending, msg)
+}
+
+// Done marks msg processed. If that advances the partition's contiguous
+// processed prefix, it returns the last message of the prefix, which is
+// the one to commit.
+func (t *OffsetTracker) Done(msg kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[topicPartition{msg.Topic, msg.Partition}]
+	if !ok {
+		return kafka.Message{}, false
+	}
+	p.done[msg.Offset] = true
+
+	var commit kafka.Message
+	advanced := false
+	for len(p.pending) > 0 && p.done[p.pending[0].Offset] {
+		commit = p.pending[0]
+		delete(p.done, commit.Offset)
+		p.pending = p.pending[1:]
+		advanced = true
+	}
+	return commit, advanced
+}
+
+// Pending returns the number of tracked messages not yet committable.
+func (t *OffsetTracker) Pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for _, p := range t.partitions {
+		n += len(p.pending)
+	}
+	return n
+}
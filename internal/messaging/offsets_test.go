@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ──────────────────────────────────────────────────────────────
+// Offset tracker tests
+// ──────────────────────────────────────────────────────────────
+
+func msgAt(partition int, offset int64) kafka.Message {
+	return kafka.Message{Topic: "events", Partition: partition, Offset: offset}
+}
+
+func TestOffsetTracker_InOrderCompletion(t *testing.T) {
+	tr := NewOffsetTracker()
+	for i := int64(0); i < 3; i++ {
+		tr.Track(msgAt(0, i))
+	}
+
+	for i := int64(0); i < 3; i++ {
+		commit, ok := tr.Done(msgAt(0, i))
+		if !ok || commit.Offset != i {
+			t.Fatalf("Done(%d): expected commit at %d, got %d (ok=%v)", i, i, commit.Offset, ok)
+		}
+	}
+	if n := tr.Pending(); n != 0 {
+		t.Errorf("expected 0 pending, got %d", n)
+	}
+}
+
+func TestOffsetTracker_OutOfOrderWaitsForGap(t *testing.T) {
+	tr := NewOffsetTracker()
+	for i := int64(10); i < 14; i++ {
+		tr.Track(msgAt(0, i))
+	}
+
+	if _, ok := tr.Done(msgAt(0, 12)); ok {
+		t.Fatal("expected no commit while 10 and 11 are in flight")
+	}
+	if _, ok := tr.Done(msgAt(0, 11)); ok {
+		t.Fatal("expected no commit while 10 is in flight")
+	}
+	commit, ok := tr.Done(msgAt(0, 10))
+	if !ok || commit.Offset != 12 {
+		t.Fatalf("expected commit to jump to 12, got %d (ok=%v)", commit.Offset, ok)
+	}
+	if n := tr.Pending(); n != 1 {
+		t.Errorf("expected 1 pending, got %d", n)
+	}
+}
+
+func TestOffsetTracker_PartitionsAreIndependent(t *testing.T) {
+	tr := NewOffsetTracker()
+	tr.Track(msgAt(0, 5))
+	tr.Track(msgAt(1, 7))
+
+	commit, ok := tr.Done(msgAt(1, 7))
+	if !ok || commit.Partition != 1 || commit.Offset != 7 {
+		t.Fatalf("expected partition 1 to commit 7 regardless of partition 0, got p%d@%d (ok=%v)",
+			commit.Partition, commit.Offset, ok)
+	}
+}
+
+func TestOffsetTracker_UntrackedMessage(t *testing.T) {
+	tr := NewOffsetTracker()
+	if _, ok := tr.Done(msgAt(3, 1)); ok {
+		t.Error("expected no commit for an untracked partition")
+	}
+}
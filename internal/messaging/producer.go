@@ -3,8 +3,12 @@ package messaging
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tracing"
 	"github.com/segmentio/kafka-go"
 )
 
@@ -13,12 +17,14 @@ type Producer struct {
 	topic  string
 }
 
+// NewProducer returns a producer for topic. Messages are partitioned by
+// key, so every publish of one key lands on the same partition.
 func NewProducer(brokers []string, topic string) *Producer {
 	w := &kafka.Writer{
 		Addr:         kafka.TCP(brokers...),
 		Topic:        topic,
 		RequiredAcks: kafka.RequireAll,
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     &kafka.Hash{},
 		BatchTimeout: 10 * time.Millisecond,
 	}
 
@@ -28,19 +34,138 @@ func NewProducer(brokers []string, topic string) *Producer {
 	}
 }
 
-func (p *Producer) Publish(ctx context.Context, key string, event any) error {
+// EventKey is the message key of an event. Including the tenant keeps
+// event IDs from different tenants apart, and the event ID spreads each
+// tenant's traffic over every partition.
+func EventKey(tenantID, eventID string) string {
+	return tenantID + "/" + eventID
+}
+
+// Publish marshals event to JSON and writes it keyed by key. Optional
+// headers are attached to the Kafka message as-is, followed by the trace
+// context of ctx.
+func (p *Producer) Publish(ctx context.Context, key string, event any, headers ...kafka.Header) (err error) {
+	ctx, span := startPublishSpan(ctx, p.topic, 1)
+	defer func() { tracing.End(span, err) }()
+
 	value, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
 
 	msg := kafka.Message{
-		Key:   []byte(key),
-		Value: value,
-		Time:  time.Now(),
+		Key:     []byte(key),
+		Value:   value,
+		Time:    time.Now(),
+		Headers: append([]kafka.Header(nil), headers...),
 	}
+	InjectTrace(ctx, &msg)
+
+	start := time.Now()
+	err = p.writer.WriteMessages(ctx, msg)
+	observePublish("single", start, err)
+	return err
+}
 
-	return p.writer.WriteMessages(ctx, msg)
+func observePublish(op string, start time.Time, err error) {
+	metrics.PublishDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.PublishFailures.WithLabelValues(op).Inc()
+	}
+}
+
+// KeyedEvent is one entry of a PublishBatch call.
+type KeyedEvent struct {
+	Key   string
+	Event any
+}
+
+// BatchError reports per-message failures from PublishBatch.
+// Errs is aligned with the input slice; nil entries were written.
+type BatchError struct {
+	Errs []error
+}
+
+func (e *BatchError) Error() string {
+	failed := 0
+	for _, err := range e.Errs {
+		if err != nil {
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d messages failed", failed, len(e.Errs))
+}
+
+// Written returns how many of n messages, counted from the first, a
+// PublishBatch returning err wrote: all of them on success, the prefix
+// before the first failure on a *BatchError, and none otherwise.
+func Written(n int, err error) int {
+	if err == nil {
+		return n
+	}
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		return 0
+	}
+	for i, err := range batchErr.Errs {
+		if err != nil {
+			return i
+		}
+	}
+	return len(batchErr.Errs)
+}
+
+// PublishBatch marshals and writes all events in a single WriteMessages
+// call. On partial failure it returns a *BatchError; any other error
+// applies to the whole batch.
+func (p *Producer) PublishBatch(ctx context.Context, batch []KeyedEvent) (err error) {
+	ctx, span := startPublishSpan(ctx, p.topic, len(batch))
+	defer func() { tracing.End(span, err) }()
+
+	msgs := make([]kafka.Message, len(batch))
+	now := time.Now()
+	for i, item := range batch {
+		value, err := json.Marshal(item.Event)
+		if err != nil {
+			return fmt.Errorf("marshal event %s: %w", item.Key, err)
+		}
+		msgs[i] = kafka.Message{Key: []byte(item.Key), Value: value, Time: now}
+		InjectTrace(ctx, &msgs[i])
+	}
+
+	err = p.writer.WriteMessages(ctx, msgs...)
+	observePublish("batch", now, err)
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		return &BatchError{Errs: writeErrs}
+	}
+	return err
+}
+
+// CheckBrokers verifies that at least one broker is reachable and serves
+// metadata for topic.
+func CheckBrokers(ctx context.Context, brokers []string, topic string) error {
+	var lastErr error
+	for _, broker := range brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		_, err = conn.ReadPartitions(topic)
+		conn.Close()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		return errors.New("no brokers configured")
+	}
+	return fmt.Errorf("kafka metadata for %s: %w", topic, lastErr)
 }
 
 func (p *Producer) Close() error {
@@ -0,0 +1,25 @@
+package messaging
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWritten(t *testing.T) {
+	broker := errors.New("broker down")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, 3},
+		{"whole batch failed", broker, 0},
+		{"first failure in the middle", &BatchError{Errs: []error{nil, broker, nil}}, 1},
+		{"first message failed", &BatchError{Errs: []error{broker, nil, nil}}, 0},
+	}
+	for _, tc := range tests {
+		if got := Written(3, tc.err); got != tc.want {
+			t.Errorf("%s: expected %d written, got %d", tc.name, tc.want, got)
+		}
+	}
+}
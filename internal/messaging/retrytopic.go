@@ -0,0 +1,148 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ──────────────────────────────────────────────────────────────
+// Tiered retry topics (non-blocking retry)
+// ──────────────────────────────────────────────────────────────
+
+// Headers carried by messages re-published to a retry tier.
+const (
+	RetryAttemptHeader   = "retry-attempt"    // tiers already visited, 1-based
+	RetryNotBeforeHeader = "retry-not-before" // RFC 3339 time before which the message must not be processed
+	RetryErrorHeader     = "retry-error"      // last failure, for operators
+)
+
+// RetryTier is one delayed retry topic.
+type RetryTier struct {
+	Topic string
+	Delay time.Duration
+}
+
+// ParseRetryTiers parses a comma-separated list of delays such as
+// "1s,30s,5m" into tiers named <baseTopic>.retry.<delay>. An empty spec
+// returns no tiers.
+func ParseRetryTiers(baseTopic, spec string) ([]RetryTier, error) {
+	var tiers []RetryTier
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid retry tier delay %q", part)
+		}
+		if n := len(tiers); n > 0 && d < tiers[n-1].Delay {
+			return nil, fmt.Errorf("retry tier delays must not decrease: %s after %s", part, tiers[n-1].Delay)
+		}
+		tiers = append(tiers, RetryTier{Topic: baseTopic + ".retry." + part, Delay: d})
+	}
+	return tiers, nil
+}
+
+// RetryAttempt returns how many retry tiers msg has already been through.
+func RetryAttempt(msg kafka.Message) int {
+	n, _ := strconv.Atoi(header(msg, RetryAttemptHeader))
+	return n
+}
+
+// RetryNotBefore returns when msg becomes eligible for processing, or the
+// zero time if it carries no deadline.
+func RetryNotBefore(msg kafka.Message) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, header(msg, RetryNotBeforeHeader))
+	return t
+}
+
+// WaitNotBefore blocks until msg is eligible for processing or ctx ends.
+func WaitNotBefore(ctx context.Context, msg kafka.Message) error {
+	wait := time.Until(RetryNotBefore(msg))
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RetryRouter re-publishes transiently failed messages to the next retry
+// tier instead of sleeping in the consume loop.
+type RetryRouter struct {
+	writer *kafka.Writer
+	tiers  []RetryTier
+}
+
+// NewRetryRouter creates a router writing to the given tiers.
+func NewRetryRouter(brokers []string, tiers []RetryTier) *RetryRouter {
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		RequiredAcks: kafka.RequireAll,
+		Balancer:     &kafka.Hash{}, // keep a key on one partition per tier
+		BatchTimeout: 10 * time.Millisecond,
+	}
+	return &RetryRouter{writer: w, tiers: tiers}
+}
+
+// Tiers returns the configured tiers, shortest delay first.
+func (r *RetryRouter) Tiers() []RetryTier {
+	return r.tiers
+}
+
+// Route publishes msg to the tier after the ones it has visited, with the
+// attempt count and not-before time in its headers. It returns false,
+// without publishing, once msg has been through every tier; the caller
+// should then dead-letter it.
+func (r *RetryRouter) Route(ctx context.Context, msg kafka.Message, reason error) (bool, error) {
+	attempt := RetryAttempt(msg)
+	if attempt >= len(r.tiers) {
+		return false, nil
+	}
+	tier := r.tiers[attempt]
+
+	headers := make([]kafka.Header, 0, len(msg.Headers)+3)
+	for _, h := range msg.Headers {
+		switch h.Key {
+		case RetryAttemptHeader, RetryNotBeforeHeader, RetryErrorHeader:
+		default:
+			headers = append(headers, h)
+		}
+	}
+	headers = append(headers,
+		kafka.Header{Key: RetryAttemptHeader, Value: []byte(strconv.Itoa(attempt + 1))},
+		kafka.Header{Key: RetryNotBeforeHeader, Value: []byte(time.Now().Add(tier.Delay).UTC().Format(time.RFC3339Nano))},
+		kafka.Header{Key: RetryErrorHeader, Value: []byte(reason.Error())},
+	)
+
+	out := kafka.Message{Topic: tier.Topic, Key: msg.Key, Value: msg.Value, Headers: headers}
+	if err := r.writer.WriteMessages(ctx, out); err != nil {
+		return false, fmt.Errorf("write to retry topic %s: %w", tier.Topic, err)
+	}
+	return true, nil
+}
+
+// Close shuts down the retry writer.
+func (r *RetryRouter) Close() error {
+	return r.writer.Close()
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
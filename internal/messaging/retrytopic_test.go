@@ -0,0 +1,91 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ──────────────────────────────────────────────────────────────
+// Retry topic tests
+// ──────────────────────────────────────────────────────────────
+
+func TestParseRetryTiers(t *testing.T) {
+	tiers, err := ParseRetryTiers("events", "1s, 30s,5m")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []RetryTier{
+		{Topic: "events.retry.1s", Delay: time.Second},
+		{Topic: "events.retry.30s", Delay: 30 * time.Second},
+		{Topic: "events.retry.5m", Delay: 5 * time.Minute},
+	}
+	if len(tiers) != len(want) {
+		t.Fatalf("expected %d tiers, got %d", len(want), len(tiers))
+	}
+	for i := range want {
+		if tiers[i] != want[i] {
+			t.Errorf("tier %d: expected %+v, got %+v", i, want[i], tiers[i])
+		}
+	}
+}
+
+func TestParseRetryTiers_Empty(t *testing.T) {
+	tiers, err := ParseRetryTiers("events", "")
+	if err != nil || len(tiers) != 0 {
+		t.Errorf("expected no tiers, got %v (err=%v)", tiers, err)
+	}
+}
+
+func TestParseRetryTiers_Invalid(t *testing.T) {
+	for _, spec := range []string{"soon", "0s", "-1s", "30s,1s"} {
+		if _, err := ParseRetryTiers("events", spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestRetryHeaders(t *testing.T) {
+	notBefore := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+	msg := kafka.Message{Headers: []kafka.Header{
+		{Key: RetryAttemptHeader, Value: []byte("2")},
+		{Key: RetryNotBeforeHeader, Value: []byte(notBefore.Format(time.RFC3339Nano))},
+	}}
+
+	if got := RetryAttempt(msg); got != 2 {
+		t.Errorf("expected attempt 2, got %d", got)
+	}
+	if got := RetryNotBefore(msg); !got.Equal(notBefore) {
+		t.Errorf("expected not-before %v, got %v", notBefore, got)
+	}
+	if got := RetryAttempt(kafka.Message{}); got != 0 {
+		t.Errorf("expected attempt 0 without headers, got %d", got)
+	}
+}
+
+func TestWaitNotBefore_RespectsContext(t *testing.T) {
+	msg := kafka.Message{Headers: []kafka.Header{
+		{Key: RetryNotBeforeHeader, Value: []byte(time.Now().Add(time.Hour).Format(time.RFC3339Nano))},
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := WaitNotBefore(ctx, msg); err == nil {
+		t.Error("expected context error while waiting")
+	}
+	if err := WaitNotBefore(context.Background(), kafka.Message{}); err != nil {
+		t.Errorf("expected no wait without a deadline, got %v", err)
+	}
+}
+
+func TestRetryRouter_ExhaustedDoesNotPublish(t *testing.T) {
+	r := &RetryRouter{tiers: []RetryTier{{Topic: "events.retry.1s", Delay: time.Second}}}
+	msg := kafka.Message{Headers: []kafka.Header{{Key: RetryAttemptHeader, Value: []byte("1")}}}
+
+	routed, err := r.Route(context.Background(), msg, NewTransient("db down", nil))
+	if routed || err != nil {
+		t.Errorf("expected exhausted message to be left for the DLQ, got routed=%v err=%v", routed, err)
+	}
+}
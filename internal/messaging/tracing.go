@@ -0,0 +1,88 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tracing"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerCarrier adapts Kafka message headers to the OpenTelemetry
+// propagation API.
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set replaces an existing header so re-published messages do not carry
+// two traceparents.
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, len(*c.headers))
+	for i, h := range *c.headers {
+		keys[i] = h.Key
+	}
+	return keys
+}
+
+// InjectTrace writes the trace context of ctx into msg's headers.
+func InjectTrace(ctx context.Context, msg *kafka.Message) {
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{&msg.Headers})
+}
+
+// ExtractTrace returns ctx carrying the trace context injected into msg
+// by its producer, if any.
+func ExtractTrace(ctx context.Context, msg kafka.Message) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier{&msg.Headers})
+}
+
+// StartProcessSpan starts a consumer span for msg, parented by the span
+// that published it.
+func StartProcessSpan(ctx context.Context, msg kafka.Message) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ExtractTrace(ctx, msg), "process "+msg.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(messageAttributes(msg)...),
+	)
+}
+
+// startPublishSpan starts a producer span for a write to topic.
+func startPublishSpan(ctx context.Context, topic string, count int) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", topic),
+			attribute.Int("messaging.batch.message_count", count),
+		),
+	)
+}
+
+func messageAttributes(msg kafka.Message) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.destination.name", msg.Topic),
+		attribute.Int("messaging.kafka.destination.partition", msg.Partition),
+		attribute.Int64("messaging.kafka.message.offset", msg.Offset),
+		attribute.String("messaging.kafka.message.key", string(msg.Key)),
+	}
+}
@@ -0,0 +1,75 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ──────────────────────────────────────────────────────────────
+// Trace propagation tests
+// ──────────────────────────────────────────────────────────────
+
+func withTraceContext(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+}
+
+func testSpanContext() trace.SpanContext {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+}
+
+func TestTrace_RoundTripThroughHeaders(t *testing.T) {
+	withTraceContext(t)
+	sc := testSpanContext()
+
+	msg := kafka.Message{Headers: []kafka.Header{{Key: RetryAttemptHeader, Value: []byte("1")}}}
+	InjectTrace(trace.ContextWithSpanContext(context.Background(), sc), &msg)
+
+	if got := header(msg, "traceparent"); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("unexpected traceparent %q", got)
+	}
+	if header(msg, RetryAttemptHeader) != "1" {
+		t.Error("existing headers must be kept")
+	}
+
+	got := trace.SpanContextFromContext(ExtractTrace(context.Background(), msg))
+	if got.TraceID() != sc.TraceID() || got.SpanID() != sc.SpanID() {
+		t.Errorf("expected %v, got %v", sc, got)
+	}
+}
+
+func TestTrace_InjectReplacesExisting(t *testing.T) {
+	withTraceContext(t)
+
+	msg := kafka.Message{Headers: []kafka.Header{{Key: "traceparent", Value: []byte("stale")}}}
+	InjectTrace(trace.ContextWithSpanContext(context.Background(), testSpanContext()), &msg)
+
+	if len(msg.Headers) != 1 {
+		t.Fatalf("expected one header, got %d", len(msg.Headers))
+	}
+	if header(msg, "traceparent") == "stale" {
+		t.Error("stale traceparent was not replaced")
+	}
+}
+
+func TestTrace_ExtractWithoutHeaders(t *testing.T) {
+	withTraceContext(t)
+
+	ctx := ExtractTrace(context.Background(), kafka.Message{})
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		t.Error("expected no span context")
+	}
+}
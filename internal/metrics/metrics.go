@@ -0,0 +1,145 @@
+// Package metrics defines the Prometheus metrics exported by the API and
+// the consumers on /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric below plus Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ── Ingestion ─────────────────────────────────────────────────
+
+var (
+	// EventsAccepted counts events published or spooled, by endpoint
+	// ("single", "batch", "stream"). Async events are counted once their
+	// publish settles, not at their 202.
+	EventsAccepted = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_accepted_total",
+		Help: "Events accepted by the ingestion API.",
+	}, []string{"endpoint"})
+
+	// EventsRejected counts events not accepted, by endpoint and reason
+	// ("invalid" for validation failures, "failed" when they could not be
+	// published or validated, "quota_exceeded" when the tenant's daily
+	// quota was used up). Async events that could be neither published
+	// nor spooled after their 202 count as "failed" and not as accepted,
+	// so each event is in exactly one of the two series.
+	EventsRejected = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_rejected_total",
+		Help: "Events rejected by the ingestion API.",
+	}, []string{"endpoint", "reason"})
+
+	// PublishDuration observes Kafka writes, by operation ("single",
+	// "batch").
+	PublishDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_publish_duration_seconds",
+		Help:    "Time to write to Kafka, including the broker ack.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"op"})
+
+	// PublishFailures counts failed Kafka writes, by operation.
+	PublishFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_publish_failures_total",
+		Help: "Kafka writes that returned an error.",
+	}, []string{"op"})
+
+	// HTTPRequestDuration observes API requests by method, chi route
+	// pattern and status code.
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// RateLimited counts requests refused with 429, by the limit that
+	// refused them ("ip", "key", "tenant").
+	RateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limited_requests_total",
+		Help: "Requests refused by a rate limit.",
+	}, []string{"limit"})
+
+	// RateLimitStoreErrors counts shared rate limit lookups that failed
+	// and fell back to per-replica limits.
+	RateLimitStoreErrors = factory.NewCounter(prometheus.CounterOpts{
+		Name: "rate_limit_store_errors_total",
+		Help: "Shared rate limit lookups that fell back to local limits.",
+	})
+	// SpoolQuarantined counts spool segments set aside because a record
+	// in them was corrupt.
+	SpoolQuarantined = factory.NewCounter(prometheus.CounterOpts{
+		Name: "spool_segments_quarantined_total",
+		Help: "Spool segments quarantined after a corrupt record.",
+	})
+)
+
+// ObserveSpool exports the spool depth reported by stats as the
+// spool_records and spool_bytes gauges, read on every scrape. Call it once.
+func ObserveSpool(stats func() (records, bytes int64)) {
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "spool_records",
+		Help: "Events waiting in the local spool.",
+	}, func() float64 {
+		records, _ := stats()
+		return float64(records)
+	})
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "spool_bytes",
+		Help: "Bytes on disk in the local spool.",
+	}, func() float64 {
+		_, bytes := stats()
+		return float64(bytes)
+	})
+}
+
+// ── Consumer ──────────────────────────────────────────────────
+
+var (
+	// InsertDuration observes event inserts, by operation ("row",
+	// "batch").
+	InsertDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_insert_duration_seconds",
+		Help:    "Time to insert events into PostgreSQL.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"op"})
+
+	// Retries counts insert failures that were retried, by ErrorKind.
+	Retries = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "consumer_retries_total",
+		Help: "Failed inserts scheduled for another attempt.",
+	}, []string{"kind"})
+
+	// DLQRoutes counts messages sent to the dead-letter topic, by reason
+	// (the ErrorKind of the final failure).
+	DLQRoutes = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "dlq_messages_total",
+		Help: "Messages routed to the dead-letter topic.",
+	}, []string{"reason"})
+
+	// ConsumerLag is the distance to the high watermark seen on the last
+	// fetch from each partition.
+	ConsumerLag = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "consumer_lag_messages",
+		Help: "Messages between the last fetched offset and the partition high watermark.",
+	}, []string{"topic", "partition"})
+)
+
+// Handler serves Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
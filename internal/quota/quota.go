@@ -0,0 +1,160 @@
+// Package quota enforces per-tenant daily event quotas.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits are daily event quotas. A limit of 0 is unlimited.
+type Limits struct {
+	Default int64
+	Tenants map[string]int64 // per-tenant overrides of Default
+}
+
+// For returns the daily quota of tenantID.
+func (l Limits) For(tenantID string) int64 {
+	if n, ok := l.Tenants[tenantID]; ok {
+		return n
+	}
+	return l.Default
+}
+
+// ParseTenantLimits parses per-tenant overrides such as
+// "acme=1000000,beta=50000".
+func ParseTenantLimits(s string) (map[string]int64, error) {
+	limits := map[string]int64{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, value, ok := strings.Cut(item, "=")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("tenant quota %q: want tenant=limit", item)
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("tenant quota %q: limit must be a non-negative integer", item)
+		}
+		limits[id] = n
+	}
+	return limits, nil
+}
+
+// Counter counts the events a tenant has stored.
+type Counter interface {
+	CountEventsSince(ctx context.Context, tenantID string, since time.Time) (int64, error)
+}
+
+type usage struct {
+	day      time.Time // UTC midnight the counts belong to
+	stored   int64     // events stored as of loadedAt
+	accepted int64     // events this replica accepted since loadedAt
+	loadedAt time.Time
+}
+
+// Limiter charges accepted events to their tenant's quota for the current
+// UTC day.
+//
+// A tenant's usage is its stored events, reloaded every refresh interval,
+// plus what this replica accepted since. Events still in Kafka, or
+// accepted by other replicas since the last reload, are not yet counted,
+// so a tenant can overshoot its quota by about one refresh interval of
+// traffic. If the count cannot be loaded the last known usage is kept,
+// so an unavailable database never blocks ingestion.
+type Limiter struct {
+	counter Counter
+	limits  Limits
+	refresh time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	usage map[string]*usage
+}
+
+// New returns a Limiter enforcing limits, reloading usage from counter
+// every refresh.
+func New(counter Counter, limits Limits, refresh time.Duration) *Limiter {
+	if refresh <= 0 {
+		refresh = 30 * time.Second
+	}
+	return &Limiter{
+		counter: counter,
+		limits:  limits,
+		refresh: refresh,
+		now:     time.Now,
+		usage:   make(map[string]*usage),
+	}
+}
+
+// Allow reports whether tenantID may ingest n more events today and, if
+// so, charges them. When it may not, reset is when the quota renews.
+// Events charged but then not accepted are returned with Refund.
+func (l *Limiter) Allow(ctx context.Context, tenantID string, n int) (ok bool, reset time.Time) {
+	limit := l.limits.For(tenantID)
+	now := l.now().UTC()
+	day := now.Truncate(24 * time.Hour)
+	reset = day.Add(24 * time.Hour)
+	if limit <= 0 {
+		return true, reset
+	}
+
+	l.reload(ctx, tenantID, day, now)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	u := l.usage[tenantID]
+	if u.stored+u.accepted+int64(n) > limit {
+		return false, reset
+	}
+	u.accepted += int64(n)
+	return true, reset
+}
+
+// Refund returns n events charged to tenantID today that were not
+// accepted after all, such as events the broker refused. Charges from an
+// earlier day are not refunded; that day's quota is gone.
+func (l *Limiter) Refund(tenantID string, n int) {
+	day := l.now().UTC().Truncate(24 * time.Hour)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	u, ok := l.usage[tenantID]
+	if !ok || !u.day.Equal(day) {
+		return
+	}
+	u.accepted = max(u.accepted-int64(n), 0)
+}
+
+// reload refreshes tenantID's stored count when it is stale or from an
+// earlier day. The query runs without holding the lock.
+func (l *Limiter) reload(ctx context.Context, tenantID string, day, now time.Time) {
+	l.mu.Lock()
+	u, ok := l.usage[tenantID]
+	if !ok || !u.day.Equal(day) {
+		u = &usage{day: day}
+		l.usage[tenantID] = u
+	}
+	if !u.loadedAt.IsZero() && now.Sub(u.loadedAt) < l.refresh {
+		l.mu.Unlock()
+		return
+	}
+	u.loadedAt = now // one reload at a time per interval, even if it fails
+	before := u.accepted
+	l.mu.Unlock()
+
+	stored, err := l.counter.CountEventsSince(ctx, tenantID, day)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	// Events accepted while the count ran may not be in it yet.
+	u.stored, u.accepted = stored, max(u.accepted-before, 0)
+	l.mu.Unlock()
+}
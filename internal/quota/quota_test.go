@@ -0,0 +1,139 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeCounter struct {
+	counts map[string]int64
+	err    error
+	calls  int
+}
+
+func (f *fakeCounter) CountEventsSince(ctx context.Context, tenantID string, since time.Time) (int64, error) {
+	f.calls++
+	return f.counts[tenantID], f.err
+}
+
+func TestLimiter_ChargesStoredAndAccepted(t *testing.T) {
+	l := New(&fakeCounter{counts: map[string]int64{"acme": 6}}, Limits{Default: 10}, time.Minute)
+	l.now = func() time.Time { return time.Date(2026, 3, 14, 15, 0, 0, 0, time.UTC) }
+
+	if ok, _ := l.Allow(context.Background(), "acme", 3); !ok {
+		t.Fatal("expected 6+3 of 10 to be allowed")
+	}
+	ok, reset := l.Allow(context.Background(), "acme", 2)
+	if ok {
+		t.Fatal("expected 9+2 of 10 to be refused")
+	}
+	if want := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC); !reset.Equal(want) {
+		t.Errorf("expected reset at %v, got %v", want, reset)
+	}
+	if ok, _ := l.Allow(context.Background(), "acme", 1); !ok {
+		t.Error("expected the last event of the quota to be allowed")
+	}
+}
+
+func TestLimiter_TenantOverridesAndUnlimited(t *testing.T) {
+	c := &fakeCounter{}
+	l := New(c, Limits{Default: 0, Tenants: map[string]int64{"beta": 1}}, time.Minute)
+
+	if ok, _ := l.Allow(context.Background(), "acme", 1000); !ok {
+		t.Error("expected a zero default to be unlimited")
+	}
+	if c.calls != 0 {
+		t.Errorf("expected unlimited tenants not to be counted, got %d calls", c.calls)
+	}
+	if ok, _ := l.Allow(context.Background(), "beta", 2); ok {
+		t.Error("expected beta's override to apply")
+	}
+}
+
+func TestLimiter_ReloadsAfterRefresh(t *testing.T) {
+	c := &fakeCounter{counts: map[string]int64{"acme": 0}}
+	l := New(c, Limits{Default: 10}, time.Minute)
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	l.Allow(context.Background(), "acme", 5)
+	l.Allow(context.Background(), "acme", 1)
+	if c.calls != 1 {
+		t.Fatalf("expected one count within the refresh interval, got %d", c.calls)
+	}
+
+	// The consumer stored this replica's events plus 3 from another one.
+	c.counts["acme"] = 9
+	now = now.Add(time.Minute)
+	if ok, _ := l.Allow(context.Background(), "acme", 2); ok {
+		t.Error("expected the reloaded count to replace this replica's tally")
+	}
+	if ok, _ := l.Allow(context.Background(), "acme", 1); !ok {
+		t.Error("expected 9+1 of 10 to be allowed")
+	}
+}
+
+func TestLimiter_NewDayResets(t *testing.T) {
+	c := &fakeCounter{counts: map[string]int64{}}
+	l := New(c, Limits{Default: 2}, time.Minute)
+	now := time.Date(2026, 3, 14, 15, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	if ok, _ := l.Allow(context.Background(), "acme", 2); !ok {
+		t.Fatal("expected the first two events to be allowed")
+	}
+	if ok, _ := l.Allow(context.Background(), "acme", 1); ok {
+		t.Fatal("expected the quota to be exhausted")
+	}
+	now = now.Add(10 * time.Hour)
+	if ok, _ := l.Allow(context.Background(), "acme", 1); !ok {
+		t.Error("expected the quota to renew at UTC midnight")
+	}
+	if c.calls != 2 {
+		t.Errorf("expected the new day to be counted afresh, got %d calls", c.calls)
+	}
+}
+
+func TestLimiter_FailsOpen(t *testing.T) {
+	c := &fakeCounter{counts: map[string]int64{"acme": 100}, err: errors.New("connection refused")}
+	l := New(c, Limits{Default: 10}, time.Minute)
+
+	if ok, _ := l.Allow(context.Background(), "acme", 1); !ok {
+		t.Error("expected events to be allowed while usage cannot be loaded")
+	}
+}
+
+func TestParseTenantLimits(t *testing.T) {
+	limits, err := ParseTenantLimits(" acme=1000000, beta=0,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limits["acme"] != 1000000 || limits["beta"] != 0 || len(limits) != 2 {
+		t.Errorf("unexpected limits %v", limits)
+	}
+
+	for _, bad := range []string{"acme", "=5", "acme=-1", "acme=lots"} {
+		if _, err := ParseTenantLimits(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestLimiter_RefundReturnsCharges(t *testing.T) {
+	l := New(&fakeCounter{}, Limits{Default: 3}, time.Minute)
+
+	if ok, _ := l.Allow(context.Background(), "acme", 3); !ok {
+		t.Fatal("expected the first three events to be allowed")
+	}
+	l.Refund("acme", 2)
+	if ok, _ := l.Allow(context.Background(), "acme", 2); !ok {
+		t.Fatal("expected refunded events to be charged again")
+	}
+
+	l.Refund("acme", 10)
+	if ok, _ := l.Allow(context.Background(), "acme", 4); ok {
+		t.Error("expected a refund not to go below zero")
+	}
+}
@@ -0,0 +1,225 @@
+// Package ratelimit implements token-bucket rate limits, kept in process
+// memory or shared between API replicas through PostgreSQL.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+)
+
+// Limit is a token bucket that refills at Rate tokens per second up to
+// Burst; a zero Burst allows one second's worth. A zero Rate disables
+// the limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0
+}
+
+// burst is the bucket size, at least one request.
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(math.Ceil(l.Rate), 1)
+}
+
+// Result is the outcome of taking from a bucket.
+type Result struct {
+	Allowed   bool
+	Limit     int // bucket size
+	Remaining int // whole tokens left
+	// RetryAfter is how long until the request would be allowed; zero
+	// when it was.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// take is how many tokens a request for n removes: n, but never more than
+// the bucket holds when full.
+func (l Limit) take(n int) float64 {
+	return math.Min(float64(n), l.burst())
+}
+
+// result builds the Result for a bucket holding tokens after a take of n.
+func (l Limit) result(allowed bool, tokens, n float64) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     int(l.burst()),
+		Remaining: int(math.Max(math.Floor(tokens), 0)),
+		Reset:     seconds((l.burst() - tokens) / l.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((n - tokens) / l.Rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(s, 0) * float64(time.Second))
+}
+
+// Store takes tokens from named buckets.
+type Store interface {
+	// Take removes n tokens from the bucket named key if it holds that
+	// many. A new bucket starts full. An n larger than the bucket takes
+	// the whole bucket, so a request bigger than the burst still gets
+	// through once the bucket has refilled.
+	Take(ctx context.Context, key string, limit Limit, n int) (Result, error)
+	// Return puts back the tokens a Take of n removed, for requests
+	// another limit refused. The bucket never holds more than it can.
+	Return(ctx context.Context, key string, limit Limit, n int) error
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket will have refilled
+}
+
+// Memory keeps buckets in process memory, so every replica enforces its
+// limits separately.
+type Memory struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// sweepInterval is how often Memory drops buckets that have refilled.
+const sweepInterval = time.Minute
+
+func (m *Memory) Take(ctx context.Context, key string, limit Limit, n int) (Result, error) {
+	now := m.now()
+	burst := limit.burst()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+		m.lastSweep = now
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	take := limit.take(n)
+	allowed := b.tokens >= take
+	if allowed {
+		b.tokens -= take
+	}
+	b.full = now.Add(seconds((burst - b.tokens) / limit.Rate))
+	return limit.result(allowed, b.tokens, take), nil
+}
+
+func (m *Memory) Return(ctx context.Context, key string, limit Limit, n int) error {
+	now := m.now()
+	burst := limit.burst()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		return nil // recreated full
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*limit.Rate+limit.take(n))
+	b.updated = now
+	b.full = now.Add(seconds((burst - b.tokens) / limit.Rate))
+	return nil
+}
+
+// sweep drops buckets that have refilled. A dropped bucket is recreated
+// full, so this never changes a decision.
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// BucketStore persists buckets shared between replicas.
+type BucketStore interface {
+	// TakeTokens atomically refills the bucket named key and removes n
+	// tokens if it holds that many, returning the tokens left and whether
+	// they were removed.
+	TakeTokens(ctx context.Context, key string, rate, burst, n float64) (float64, bool, error)
+	// ReturnTokens refills the bucket named key and puts back n tokens,
+	// up to burst.
+	ReturnTokens(ctx context.Context, key string, rate, burst, n float64) error
+	// DeleteRefilledBuckets removes buckets that have refilled; they
+	// would be recreated full anyway.
+	DeleteRefilledBuckets(ctx context.Context) (int64, error)
+}
+
+// Shared keeps buckets in a BucketStore so limits hold across replicas.
+// When the store fails it falls back to per-replica buckets in memory
+// rather than rejecting or admitting everything, and counts the failure
+// in rate_limit_store_errors_total.
+type Shared struct {
+	store    BucketStore
+	fallback *Memory
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewShared returns a store backed by store.
+func NewShared(store BucketStore) *Shared {
+	return &Shared{store: store, fallback: NewMemory()}
+}
+
+func (s *Shared) Take(ctx context.Context, key string, limit Limit, n int) (Result, error) {
+	take := limit.take(n)
+	tokens, allowed, err := s.store.TakeTokens(ctx, key, limit.Rate, limit.burst(), take)
+	if err != nil {
+		metrics.RateLimitStoreErrors.Inc()
+		return s.fallback.Take(ctx, key, limit, n)
+	}
+	s.maybeSweep(ctx)
+	return limit.result(allowed, tokens, take), nil
+}
+
+func (s *Shared) Return(ctx context.Context, key string, limit Limit, n int) error {
+	if err := s.store.ReturnTokens(ctx, key, limit.Rate, limit.burst(), limit.take(n)); err != nil {
+		metrics.RateLimitStoreErrors.Inc()
+		return s.fallback.Return(ctx, key, limit, n)
+	}
+	return nil
+}
+
+// maybeSweep deletes refilled buckets at most once per sweep interval per
+// replica.
+func (s *Shared) maybeSweep(ctx context.Context) {
+	s.mu.Lock()
+	due := time.Since(s.lastSweep) >= sweepInterval
+	if due {
+		s.lastSweep = time.Now()
+	}
+	s.mu.Unlock()
+	if due {
+		s.store.DeleteRefilledBuckets(ctx)
+	}
+}
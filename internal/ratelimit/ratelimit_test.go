@@ -0,0 +1,184 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemory_BurstThenRefill(t *testing.T) {
+	m := NewMemory()
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		res, _ := m.Take(context.Background(), "k", limit, 1)
+		if !res.Allowed {
+			t.Fatalf("expected request %d of the burst to be allowed", i+1)
+		}
+		if res.Remaining != 2-i {
+			t.Errorf("expected %d remaining, got %d", 2-i, res.Remaining)
+		}
+	}
+
+	res, _ := m.Take(context.Background(), "k", limit, 1)
+	if res.Allowed {
+		t.Fatal("expected the request after the burst to be refused")
+	}
+	if res.RetryAfter != 500*time.Millisecond {
+		t.Errorf("expected retry after 500ms, got %v", res.RetryAfter)
+	}
+	if res.Reset != 1500*time.Millisecond {
+		t.Errorf("expected reset after 1.5s, got %v", res.Reset)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if res, _ := m.Take(context.Background(), "k", limit, 1); !res.Allowed {
+		t.Error("expected a refilled token to be allowed")
+	}
+}
+
+func TestMemory_RefusalDoesNotSpendTokens(t *testing.T) {
+	m := NewMemory()
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 1}
+
+	m.Take(context.Background(), "k", limit, 1)
+	for i := 0; i < 5; i++ {
+		m.Take(context.Background(), "k", limit, 1)
+	}
+	now = now.Add(time.Second)
+	if res, _ := m.Take(context.Background(), "k", limit, 1); !res.Allowed {
+		t.Error("expected refused requests not to push the next token back")
+	}
+}
+
+func TestMemory_TakeLargerThanBurst(t *testing.T) {
+	m := NewMemory()
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 5}
+
+	if res, _ := m.Take(context.Background(), "k", limit, 20); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("expected a take larger than the burst to empty a full bucket, got %+v", res)
+	}
+	res, _ := m.Take(context.Background(), "k", limit, 20)
+	if res.Allowed {
+		t.Fatal("expected the next large take to wait for the bucket to refill")
+	}
+	if res.RetryAfter != 5*time.Second {
+		t.Errorf("expected retry after 5s, got %v", res.RetryAfter)
+	}
+	now = now.Add(5 * time.Second)
+	if res, _ := m.Take(context.Background(), "k", limit, 20); !res.Allowed {
+		t.Error("expected the refilled bucket to allow it again")
+	}
+}
+
+func TestMemory_ReturnPutsTokensBack(t *testing.T) {
+	m := NewMemory()
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 3}
+
+	m.Take(context.Background(), "k", limit, 3)
+	m.Return(context.Background(), "k", limit, 2)
+	if res, _ := m.Take(context.Background(), "k", limit, 2); !res.Allowed {
+		t.Fatal("expected returned tokens to be available again")
+	}
+
+	m.Return(context.Background(), "k", limit, 10)
+	if res, _ := m.Take(context.Background(), "k", limit, 3); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("expected a return to fill the bucket no further than its size, got %+v", res)
+	}
+}
+
+func TestMemory_KeysAreIndependent(t *testing.T) {
+	m := NewMemory()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	m.Take(context.Background(), "a", limit, 1)
+	if res, _ := m.Take(context.Background(), "b", limit, 1); !res.Allowed {
+		t.Error("expected another key to have its own bucket")
+	}
+}
+
+func TestMemory_SweepDropsRefilledBuckets(t *testing.T) {
+	m := NewMemory()
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	m.Take(context.Background(), "fast", Limit{Rate: 10, Burst: 10}, 1)
+	m.Take(context.Background(), "slow", Limit{Rate: 0.001, Burst: 10}, 1)
+
+	now = now.Add(2 * time.Minute)
+	m.Take(context.Background(), "other", Limit{Rate: 10}, 1)
+
+	if _, ok := m.buckets["fast"]; ok {
+		t.Error("expected the refilled bucket to be swept")
+	}
+	if _, ok := m.buckets["slow"]; !ok {
+		t.Error("expected the slow bucket to be kept until it refills")
+	}
+}
+
+func TestLimit_DefaultBurst(t *testing.T) {
+	if got := (Limit{Rate: 2.5}).burst(); got != 3 {
+		t.Errorf("expected one second's worth rounded up, got %v", got)
+	}
+	if got := (Limit{Rate: 0.1}).burst(); got != 1 {
+		t.Errorf("expected a burst of at least one, got %v", got)
+	}
+}
+
+type fakeBuckets struct {
+	tokens  float64
+	allowed bool
+	err     error
+	sweeps  int
+}
+
+func (f *fakeBuckets) TakeTokens(ctx context.Context, key string, rate, burst, n float64) (float64, bool, error) {
+	return f.tokens, f.allowed, f.err
+}
+
+func (f *fakeBuckets) ReturnTokens(ctx context.Context, key string, rate, burst, n float64) error {
+	return f.err
+}
+
+func (f *fakeBuckets) DeleteRefilledBuckets(ctx context.Context) (int64, error) {
+	f.sweeps++
+	return 0, nil
+}
+
+func TestShared_UsesStore(t *testing.T) {
+	store := &fakeBuckets{tokens: 0.5, allowed: false}
+	s := NewShared(store)
+
+	res, err := s.Take(context.Background(), "k", Limit{Rate: 1, Burst: 5}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 500*time.Millisecond {
+		t.Errorf("unexpected result %+v", res)
+	}
+	s.Take(context.Background(), "k", Limit{Rate: 1, Burst: 5}, 1)
+	if store.sweeps != 1 {
+		t.Errorf("expected one sweep per interval, got %d", store.sweeps)
+	}
+}
+
+func TestShared_FallsBackToMemory(t *testing.T) {
+	s := NewShared(&fakeBuckets{err: errors.New("connection refused")})
+	limit := Limit{Rate: 1, Burst: 1}
+
+	if res, err := s.Take(context.Background(), "k", limit, 1); err != nil || !res.Allowed {
+		t.Fatalf("expected the fallback to allow the first request, got %+v, %v", res, err)
+	}
+	if res, _ := s.Take(context.Background(), "k", limit, 1); res.Allowed {
+		t.Error("expected the fallback to enforce the limit locally")
+	}
+}
@@ -0,0 +1,179 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// FieldError is one schema violation, located by JSON Pointer into the payload.
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when a payload does not match the active schema.
+type ValidationError struct {
+	EventType string
+	Version   int
+	Fields    []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("payload does not match %s schema v%d", e.EventType, e.Version)
+}
+
+// Source loads the active schema of every event type.
+type Source interface {
+	GetActiveSchemas(ctx context.Context) ([]storage.EventSchema, error)
+}
+
+type compiled struct {
+	version int
+	schema  *jsonschema.Schema
+}
+
+// Registry validates payloads against the active schema for their event
+// type. Active schemas are cached and reloaded every refresh interval so
+// changes made through any API replica propagate.
+type Registry struct {
+	source  Source
+	refresh time.Duration
+
+	mu       sync.RWMutex
+	schemas  map[string]compiled
+	loadedAt time.Time
+}
+
+// NewRegistry creates a registry that reloads from source every refresh.
+func NewRegistry(source Source, refresh time.Duration) *Registry {
+	if refresh <= 0 {
+		refresh = 30 * time.Second
+	}
+	return &Registry{source: source, refresh: refresh}
+}
+
+// Compile checks that raw is a valid JSON Schema.
+func Compile(eventType string, version int, raw json.RawMessage) (*jsonschema.Schema, error) {
+	url := fmt.Sprintf("schema://%s/v%d.json", eventType, version)
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(url, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("load schema: %w", err)
+	}
+	s, err := c.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema: %w", err)
+	}
+	return s, nil
+}
+
+// Validate checks payload against the active schema for eventType and
+// returns the schema version used, or 0 if the type has no active schema.
+// A mismatch is reported as a *ValidationError.
+func (r *Registry) Validate(ctx context.Context, eventType string, payload json.RawMessage) (int, error) {
+	if err := r.ensureFresh(ctx); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	c, ok := r.schemas[eventType]
+	r.mu.RUnlock()
+	if !ok {
+		return 0, nil
+	}
+
+	if len(payload) == 0 {
+		payload = json.RawMessage("null")
+	}
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return c.version, &ValidationError{
+			EventType: eventType,
+			Version:   c.version,
+			Fields:    []FieldError{{Path: "", Message: "payload is not valid JSON"}},
+		}
+	}
+
+	if err := c.schema.Validate(doc); err != nil {
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return c.version, err
+		}
+		return c.version, &ValidationError{EventType: eventType, Version: c.version, Fields: fieldErrors(ve)}
+	}
+	return c.version, nil
+}
+
+// Invalidate forces a reload on the next Validate, e.g. after this
+// replica changed a schema.
+func (r *Registry) Invalidate() {
+	r.mu.Lock()
+	r.loadedAt = time.Time{}
+	r.mu.Unlock()
+}
+
+// ensureFresh reloads active schemas when the cache is stale. If a reload
+// fails but a previous load succeeded, the stale cache keeps serving.
+func (r *Registry) ensureFresh(ctx context.Context) error {
+	r.mu.RLock()
+	fresh := time.Since(r.loadedAt) < r.refresh
+	loaded := r.schemas != nil
+	r.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	rows, err := r.source.GetActiveSchemas(ctx)
+	if err != nil {
+		if loaded {
+			return nil
+		}
+		return fmt.Errorf("load schemas: %w", err)
+	}
+
+	schemas := make(map[string]compiled, len(rows))
+	for _, row := range rows {
+		s, err := Compile(row.EventType, row.Version, row.Schema)
+		if err != nil {
+			// Schemas are compiled before they are stored, so this only
+			// happens if the table was edited by hand. Skip validation
+			// for the type rather than rejecting all of its events.
+			continue
+		}
+		schemas[row.EventType] = compiled{version: row.Version, schema: s}
+	}
+
+	r.mu.Lock()
+	r.schemas = schemas
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// fieldErrors flattens a validation error tree into its leaf causes.
+func fieldErrors(ve *jsonschema.ValidationError) []FieldError {
+	var out []FieldError
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			out = append(out, FieldError{Path: e.InstanceLocation, Message: e.Message})
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(ve)
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/storage"
+)
+
+type fakeSource struct {
+	schemas []storage.EventSchema
+	err     error
+	calls   int
+}
+
+func (f *fakeSource) GetActiveSchemas(ctx context.Context) ([]storage.EventSchema, error) {
+	f.calls++
+	return f.schemas, f.err
+}
+
+const purchaseSchema = `{
+	"type": "object",
+	"required": ["amount", "currency"],
+	"properties": {
+		"amount":   {"type": "number", "minimum": 0},
+		"currency": {"type": "string", "minLength": 3, "maxLength": 3}
+	}
+}`
+
+func newTestRegistry() (*Registry, *fakeSource) {
+	src := &fakeSource{schemas: []storage.EventSchema{
+		{EventType: "purchase", Version: 3, Schema: json.RawMessage(purchaseSchema), Active: true},
+	}}
+	return NewRegistry(src, time.Minute), src
+}
+
+func TestRegistry_ValidPayload(t *testing.T) {
+	r, _ := newTestRegistry()
+
+	version, err := r.Validate(context.Background(), "purchase", json.RawMessage(`{"amount":9.99,"currency":"EUR"}`))
+	if err != nil {
+		t.Fatalf("expected valid payload, got %v", err)
+	}
+	if version != 3 {
+		t.Errorf("expected schema version 3, got %d", version)
+	}
+}
+
+func TestRegistry_FieldErrors(t *testing.T) {
+	r, _ := newTestRegistry()
+
+	_, err := r.Validate(context.Background(), "purchase", json.RawMessage(`{"amount":-1,"currency":"EURO"}`))
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	if len(ve.Fields) != 2 {
+		t.Fatalf("expected 2 field errors, got %+v", ve.Fields)
+	}
+	if ve.Fields[0].Path != "/amount" || ve.Fields[1].Path != "/currency" {
+		t.Errorf("unexpected field paths: %+v", ve.Fields)
+	}
+}
+
+func TestRegistry_UnknownTypeIsUnvalidated(t *testing.T) {
+	r, _ := newTestRegistry()
+
+	version, err := r.Validate(context.Background(), "click", json.RawMessage(`"anything"`))
+	if err != nil || version != 0 {
+		t.Errorf("expected (0, nil) for a type without schema, got (%d, %v)", version, err)
+	}
+}
+
+func TestRegistry_CachesUntilInvalidated(t *testing.T) {
+	r, src := newTestRegistry()
+	ctx := context.Background()
+
+	r.Validate(ctx, "purchase", json.RawMessage(`{}`))
+	r.Validate(ctx, "purchase", json.RawMessage(`{}`))
+	if src.calls != 1 {
+		t.Errorf("expected 1 load, got %d", src.calls)
+	}
+
+	r.Invalidate()
+	r.Validate(ctx, "purchase", json.RawMessage(`{}`))
+	if src.calls != 2 {
+		t.Errorf("expected reload after Invalidate, got %d loads", src.calls)
+	}
+}
+
+func TestRegistry_StaleCacheServesOnReloadFailure(t *testing.T) {
+	r, src := newTestRegistry()
+	ctx := context.Background()
+
+	r.Validate(ctx, "purchase", json.RawMessage(`{"amount":1,"currency":"USD"}`))
+	src.err = errors.New("db down")
+	r.Invalidate()
+
+	if _, err := r.Validate(ctx, "purchase", json.RawMessage(`{"amount":1,"currency":"USD"}`)); err != nil {
+		t.Errorf("expected stale cache to serve, got %v", err)
+	}
+}
+
+func TestCompile_RejectsInvalidSchema(t *testing.T) {
+	if _, err := Compile("purchase", 1, json.RawMessage(`{"type": 42}`)); err == nil {
+		t.Error("expected compile error for invalid schema")
+	}
+}
@@ -0,0 +1,524 @@
+package spool
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/logging"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+)
+
+// ──────────────────────────────────────────────────────────────
+// Local write-ahead spool for events Kafka did not accept
+//
+// Records are appended to numbered segment files and fsynced before
+// Append returns. A drainer re-publishes sealed segments oldest-first,
+// a chunk of records per publish call, and deletes each segment once
+// every record in it was published.
+// Re-publishing after a crash may duplicate events; the consumer's
+// idempotent insert absorbs that.
+//
+// A torn record at the end of the segment that was active when the
+// previous process stopped is the trace of a crash mid-append and ends
+// that segment. Any other unreadable record is corruption: the segment is
+// renamed to <seq>.corrupt, keeping the records after it for inspection.
+//
+// Record layout: [keyLen u32][valueLen u32][crc32(key|value) u32][key][value]
+// ──────────────────────────────────────────────────────────────
+
+// ErrFull is returned by Append when the spool has reached MaxBytes.
+var ErrFull = errors.New("spool is full")
+
+const (
+	headerSize     = 12
+	segmentExt     = ".seg"
+	quarantineExt  = ".corrupt"
+	maxRecordBytes = 64 << 20 // Larger length prefixes are treated as corruption
+)
+
+// Options configures a Spool.
+type Options struct {
+	MaxBytes     int64 // Total on-disk cap across segments (default 1 GiB)
+	SegmentBytes int64 // Rotate the active segment past this size (default 64 MiB)
+	DrainBatch   int   // Records re-published per publish call (default 500)
+	// Logger reports quarantined segments.
+	Logger *logging.Logger
+}
+
+// Stats reports spool depth.
+type Stats struct {
+	Segments int   `json:"segments"`
+	Records  int64 `json:"records"`
+	Bytes    int64 `json:"bytes"`
+}
+
+// Record is a spooled event.
+type Record struct {
+	Key   string
+	Value []byte
+}
+
+// PublishFunc re-publishes a chunk of spooled records in order and
+// returns how many of them, counted from the first, were published.
+// Returning an error stops the current drain pass; the records after that
+// prefix are retried on the next pass.
+type PublishFunc func(ctx context.Context, records []Record) (int, error)
+
+// Spool is an append-only, segment-based on-disk queue.
+type Spool struct {
+	dir  string
+	opts Options
+
+	mu         sync.Mutex
+	active     *os.File
+	activeSeq  uint64
+	activeSize int64
+	sealed     []uint64 // sealed segment sequence numbers, oldest first
+	stats      Stats
+	// counts is each segment's share of stats, so a segment that leaves
+	// the spool takes exactly its own records with it.
+	counts map[uint64]*Stats
+	// crashSeq is the segment that was active when the previous process
+	// stopped, the only one that may end in a torn record.
+	crashSeq uint64
+
+	// Drain progress within the oldest sealed segment, so a failed pass
+	// resumes after the last published record instead of re-sending it.
+	drainMu     sync.Mutex
+	drainSeq    uint64
+	drainOffset int64
+}
+
+// Open opens (or creates) a spool in dir, recovering any segments left
+// by a previous process.
+func Open(dir string, opts Options) (*Spool, error) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 1 << 30
+	}
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = 64 << 20
+	}
+	if opts.DrainBatch <= 0 {
+		opts.DrainBatch = 500
+	}
+	if opts.Logger == nil {
+		opts.Logger = logging.New("spool", "info")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create spool dir: %w", err)
+	}
+
+	seqs, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Spool{dir: dir, opts: opts, counts: make(map[uint64]*Stats)}
+	for _, seq := range seqs {
+		records, size, err := scanSegment(s.path(seq))
+		if err != nil {
+			return nil, err
+		}
+		s.stats.Records += records
+		s.stats.Bytes += size
+		s.counts[seq] = &Stats{Records: records, Bytes: size}
+	}
+	// Every pre-existing segment is sealed; new appends go to a fresh one.
+	s.sealed = seqs
+	if len(seqs) > 0 {
+		s.activeSeq = seqs[len(seqs)-1]
+		s.crashSeq = s.activeSeq
+	}
+	if err := s.openNextLocked(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Append durably writes a record. It returns ErrFull if the record would
+// push the spool past MaxBytes.
+func (s *Spool) Append(key string, value []byte) error {
+	rec := encodeRecord(key, value)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return errors.New("spool is closed")
+	}
+	if s.stats.Bytes+int64(len(rec)) > s.opts.MaxBytes {
+		return ErrFull
+	}
+	if s.activeSize > 0 && s.activeSize+int64(len(rec)) > s.opts.SegmentBytes {
+		if err := s.sealLocked(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.active.Write(rec); err != nil {
+		// Drop any partial record so later appends stay readable.
+		s.active.Truncate(s.activeSize)
+		return fmt.Errorf("write spool record: %w", err)
+	}
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("sync spool segment: %w", err)
+	}
+	s.activeSize += int64(len(rec))
+	s.countLocked(s.activeSeq, 1, int64(len(rec)))
+	return nil
+}
+
+// Stats returns the current spool depth.
+func (s *Spool) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stats
+	st.Segments = len(s.sealed)
+	if s.activeSize > 0 {
+		st.Segments++
+	}
+	return st
+}
+
+// Drain re-publishes spooled records in append order until the spool is
+// empty or publish fails. It returns the number of records published.
+func (s *Spool) Drain(ctx context.Context, publish PublishFunc) (int, error) {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+
+	// Seal the active segment so everything appended so far is drainable.
+	s.mu.Lock()
+	if s.active != nil && s.activeSize > 0 {
+		if err := s.sealLocked(); err != nil {
+			s.mu.Unlock()
+			return 0, err
+		}
+	}
+	s.mu.Unlock()
+
+	published := 0
+	for {
+		s.mu.Lock()
+		if len(s.sealed) == 0 {
+			s.mu.Unlock()
+			return published, nil
+		}
+		seq := s.sealed[0]
+		s.mu.Unlock()
+
+		if seq != s.drainSeq {
+			s.drainSeq, s.drainOffset = seq, 0
+		}
+
+		n, err := s.drainSegment(ctx, seq, publish)
+		published += n
+		var corrupt *corruptError
+		switch {
+		case errors.As(err, &corrupt):
+			if err := s.quarantine(seq, corrupt); err != nil {
+				return published, err
+			}
+		case err != nil:
+			return published, err
+		default:
+			if err := os.Remove(s.path(seq)); err != nil && !os.IsNotExist(err) {
+				return published, fmt.Errorf("remove drained segment: %w", err)
+			}
+		}
+
+		s.mu.Lock()
+		s.sealed = s.sealed[1:]
+		s.forgetLocked(seq)
+		s.mu.Unlock()
+	}
+}
+
+// Run drains the spool every interval until ctx is cancelled.
+func (s *Spool) Run(ctx context.Context, interval time.Duration, publish PublishFunc, logger *logging.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if s.Stats().Records == 0 {
+			continue
+		}
+		n, err := s.Drain(ctx, publish)
+		st := s.Stats()
+		if err != nil {
+			logger.Error("spool drain stalled", map[string]any{
+				"error":     err.Error(),
+				"published": n,
+				"records":   st.Records,
+				"bytes":     st.Bytes,
+			})
+			continue
+		}
+		logger.Info("spool drained", map[string]any{
+			"published": n,
+			"records":   st.Records,
+			"bytes":     st.Bytes,
+		})
+	}
+}
+
+// Close closes the active segment. Spooled records stay on disk.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil {
+		return nil
+	}
+	err := s.active.Close()
+	s.active = nil
+	return err
+}
+
+// drainSegment publishes records of segment seq starting at the saved
+// drain offset, DrainBatch records per publish call. The offset and stats
+// advance past the prefix of each chunk that was published. A corrupt
+// record returns a *corruptError once the records before it are out.
+func (s *Spool) drainSegment(ctx context.Context, seq uint64, publish PublishFunc) (int, error) {
+	f, err := os.Open(s.path(seq))
+	if err != nil {
+		return 0, fmt.Errorf("open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(s.drainOffset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seek spool segment: %w", err)
+	}
+	r := bufio.NewReader(f)
+
+	published := 0
+	chunk := make([]Record, 0, s.opts.DrainBatch)
+	sizes := make([]int64, 0, s.opts.DrainBatch)
+	for {
+		chunk, sizes = chunk[:0], sizes[:0]
+		var readErr error
+		for len(chunk) < s.opts.DrainBatch {
+			key, value, size, err := readRecord(r)
+			if err != nil {
+				readErr = err
+				break
+			}
+			chunk = append(chunk, Record{Key: key, Value: value})
+			sizes = append(sizes, size)
+		}
+
+		if len(chunk) > 0 {
+			n, err := publish(ctx, chunk)
+			n = min(max(n, 0), len(chunk))
+			if err == nil && n < len(chunk) {
+				err = fmt.Errorf("%d of %d records acknowledged", n, len(chunk))
+			}
+			var size int64
+			for _, sz := range sizes[:n] {
+				size += sz
+			}
+			published += n
+			s.drainOffset += size
+
+			s.mu.Lock()
+			s.countLocked(seq, -int64(n), -size)
+			s.mu.Unlock()
+
+			if err != nil {
+				return published, fmt.Errorf("publish spooled records: %w", err)
+			}
+		}
+
+		switch {
+		case readErr == nil:
+		case readErr == io.EOF:
+			return published, nil
+		case errors.Is(readErr, io.ErrUnexpectedEOF) && seq == s.crashSeq:
+			// A crash mid-append; the torn record was never counted.
+			return published, nil
+		default:
+			return published, &corruptError{offset: s.drainOffset, err: readErr}
+		}
+	}
+}
+
+// corruptError reports an unreadable record that is not a torn tail.
+type corruptError struct {
+	offset int64 // where the record starts in its segment
+	err    error
+}
+
+func (e *corruptError) Error() string {
+	return fmt.Sprintf("corrupt spool record at offset %d: %v", e.offset, e.err)
+}
+
+// quarantine renames segment seq, whose record at corrupt.offset cannot be
+// read, out of the spool so draining can continue past it.
+func (s *Spool) quarantine(seq uint64, corrupt *corruptError) error {
+	dst := filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, quarantineExt))
+	if err := os.Rename(s.path(seq), dst); err != nil {
+		return fmt.Errorf("quarantine spool segment: %w", err)
+	}
+	metrics.SpoolQuarantined.Inc()
+
+	// Records of a recovered segment past the corruption were never
+	// counted, so the skipped count is a lower bound.
+	var skipped Stats
+	s.mu.Lock()
+	if c, ok := s.counts[seq]; ok {
+		skipped = *c
+	}
+	s.mu.Unlock()
+	s.opts.Logger.Error("spool segment quarantined", map[string]any{
+		"error":           corrupt.Error(),
+		"path":            dst,
+		"skipped_records": skipped.Records,
+		"skipped_bytes":   skipped.Bytes,
+	})
+	return nil
+}
+
+// countLocked adds records and bytes to segment seq's share of stats.
+func (s *Spool) countLocked(seq uint64, records, bytes int64) {
+	c, ok := s.counts[seq]
+	if !ok {
+		c = &Stats{}
+		s.counts[seq] = c
+	}
+	c.Records += records
+	c.Bytes += bytes
+	s.stats.Records += records
+	s.stats.Bytes += bytes
+}
+
+// forgetLocked drops segment seq, and whatever it still held, from stats.
+func (s *Spool) forgetLocked(seq uint64) {
+	if c, ok := s.counts[seq]; ok {
+		s.stats.Records -= c.Records
+		s.stats.Bytes -= c.Bytes
+		delete(s.counts, seq)
+	}
+}
+
+func (s *Spool) sealLocked() error {
+	if err := s.active.Close(); err != nil {
+		return fmt.Errorf("close spool segment: %w", err)
+	}
+	s.sealed = append(s.sealed, s.activeSeq)
+	return s.openNextLocked()
+}
+
+func (s *Spool) openNextLocked() error {
+	s.activeSeq++
+	f, err := os.OpenFile(s.path(s.activeSeq), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open spool segment: %w", err)
+	}
+	s.active = f
+	s.activeSize = 0
+	return nil
+}
+
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// listSegments returns existing segment sequence numbers, oldest first.
+// Empty segments are removed.
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read spool dir: %w", err)
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.Size() == 0 {
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+// scanSegment counts the valid records in a segment and their total size.
+func scanSegment(path string) (int64, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var records, size int64
+	for {
+		_, _, n, err := readRecord(r)
+		if err != nil {
+			return records, size, nil
+		}
+		records++
+		size += n
+	}
+}
+
+func encodeRecord(key string, value []byte) []byte {
+	rec := make([]byte, headerSize+len(key)+len(value))
+	binary.BigEndian.PutUint32(rec[0:4], uint32(len(key)))
+	binary.BigEndian.PutUint32(rec[4:8], uint32(len(value)))
+	copy(rec[headerSize:], key)
+	copy(rec[headerSize+len(key):], value)
+	binary.BigEndian.PutUint32(rec[8:12], crc32.ChecksumIEEE(rec[headerSize:]))
+	return rec
+}
+
+// readRecord reads one record, returning io.EOF at a clean end of segment
+// and io.ErrUnexpectedEOF or a checksum error for a torn/corrupt record.
+func readRecord(r *bufio.Reader) (string, []byte, int64, error) {
+	var hdr [headerSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if err == io.EOF {
+			return "", nil, 0, io.EOF
+		}
+		return "", nil, 0, io.ErrUnexpectedEOF
+	}
+	keyLen := binary.BigEndian.Uint32(hdr[0:4])
+	valueLen := binary.BigEndian.Uint32(hdr[4:8])
+	sum := binary.BigEndian.Uint32(hdr[8:12])
+	if uint64(keyLen)+uint64(valueLen) > maxRecordBytes {
+		return "", nil, 0, errors.New("spool record length out of range")
+	}
+
+	body := make([]byte, int(keyLen)+int(valueLen))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return "", nil, 0, io.ErrUnexpectedEOF
+	}
+	if crc32.ChecksumIEEE(body) != sum {
+		return "", nil, 0, errors.New("spool record checksum mismatch")
+	}
+	return string(body[:keyLen]), body[keyLen:], int64(headerSize + len(body)), nil
+}
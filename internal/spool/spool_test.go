@@ -0,0 +1,282 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// collect returns a PublishFunc that records keys in order.
+func collect(keys *[]string) PublishFunc {
+	return func(_ context.Context, records []Record) (int, error) {
+		for _, rec := range records {
+			*keys = append(*keys, rec.Key)
+		}
+		return len(records), nil
+	}
+}
+
+func TestSpool_DrainPreservesOrder(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{SegmentBytes: 64})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := s.Append(fmt.Sprintf("k%d", i), []byte(`{"n":1}`)); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+	if st := s.Stats(); st.Records != 10 || st.Segments < 2 {
+		t.Fatalf("expected 10 records across multiple segments, got %+v", st)
+	}
+
+	var keys []string
+	n, err := s.Drain(context.Background(), collect(&keys))
+	if err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if n != 10 {
+		t.Fatalf("expected 10 published, got %d", n)
+	}
+	for i, k := range keys {
+		if k != fmt.Sprintf("k%d", i) {
+			t.Errorf("position %d: expected k%d, got %s", i, i, k)
+		}
+	}
+	if st := s.Stats(); st.Records != 0 || st.Bytes != 0 {
+		t.Errorf("expected empty spool after drain, got %+v", st)
+	}
+}
+
+func TestSpool_ResumesAfterPublishFailure(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer s.Close()
+
+	for _, k := range []string{"a", "b", "c"} {
+		s.Append(k, []byte("{}"))
+	}
+
+	var keys []string
+	failOn := "b"
+	publish := func(ctx context.Context, records []Record) (int, error) {
+		for i, rec := range records {
+			if rec.Key == failOn {
+				return i, errors.New("broker down")
+			}
+			keys = append(keys, rec.Key)
+		}
+		return len(records), nil
+	}
+
+	n, err := s.Drain(context.Background(), publish)
+	if err == nil || n != 1 {
+		t.Fatalf("expected failure after 1 record, got n=%d err=%v", n, err)
+	}
+	if st := s.Stats(); st.Records != 2 {
+		t.Fatalf("expected 2 records left, got %+v", st)
+	}
+
+	failOn = ""
+	if _, err := s.Drain(context.Background(), publish); err != nil {
+		t.Fatalf("second drain: %v", err)
+	}
+	if len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+		t.Errorf("expected [a b c] without duplicates, got %v", keys)
+	}
+}
+
+func TestSpool_DrainPublishesInChunks(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{DrainBatch: 4})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 10; i++ {
+		s.Append(fmt.Sprintf("k%d", i), []byte("{}"))
+	}
+
+	var sizes []int
+	n, err := s.Drain(context.Background(), func(_ context.Context, records []Record) (int, error) {
+		sizes = append(sizes, len(records))
+		return len(records), nil
+	})
+	if err != nil || n != 10 {
+		t.Fatalf("expected 10 published, got n=%d err=%v", n, err)
+	}
+	if len(sizes) != 3 || sizes[0] != 4 || sizes[1] != 4 || sizes[2] != 2 {
+		t.Errorf("expected chunks of [4 4 2], got %v", sizes)
+	}
+}
+
+func TestSpool_ShortAckWithoutErrorStopsDrain(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer s.Close()
+
+	for _, k := range []string{"a", "b", "c"} {
+		s.Append(k, []byte("{}"))
+	}
+	n, err := s.Drain(context.Background(), func(_ context.Context, records []Record) (int, error) {
+		return 1, nil
+	})
+	if err == nil || n != 1 {
+		t.Fatalf("expected the drain to stop after 1 record, got n=%d err=%v", n, err)
+	}
+	if st := s.Stats(); st.Records != 2 {
+		t.Errorf("expected 2 records left, got %+v", st)
+	}
+}
+
+func TestSpool_RecoversAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	s.Append("x", []byte("1"))
+	s.Append("y", []byte("2"))
+	s.Close()
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+
+	if st := s.Stats(); st.Records != 2 {
+		t.Fatalf("expected 2 recovered records, got %+v", st)
+	}
+	var keys []string
+	if _, err := s.Drain(context.Background(), collect(&keys)); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "x" || keys[1] != "y" {
+		t.Errorf("expected [x y], got %v", keys)
+	}
+}
+
+func TestSpool_TornTailIsIgnored(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := Open(dir, Options{})
+	s.Append("ok", []byte("{}"))
+	s.Close()
+
+	// Simulate a crash mid-append: a header with no body.
+	f, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%020d%s", 1, segmentExt)), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("open segment: %v", err)
+	}
+	f.Write([]byte{0, 0, 0, 5, 0, 0, 0, 5})
+	f.Close()
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+
+	var keys []string
+	if _, err := s.Drain(context.Background(), collect(&keys)); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "ok" {
+		t.Errorf("expected only the intact record, got %v", keys)
+	}
+}
+
+func TestSpool_MaxBytes(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{MaxBytes: 40})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Append("k", make([]byte, 20)); err != nil {
+		t.Fatalf("first append: %v", err)
+	}
+	if err := s.Append("k", make([]byte, 20)); !errors.Is(err, ErrFull) {
+		t.Errorf("expected ErrFull, got %v", err)
+	}
+}
+
+func TestSpool_CorruptSegmentIsQuarantined(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentBytes: 64})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer s.Close()
+
+	// Segment 1 holds a, b and c; d goes to segment 2.
+	for _, k := range []string{"a", "b", "c", "d"} {
+		s.Append(k, []byte(`{"n":1}`))
+	}
+
+	// Flip a byte of b's value.
+	seg := filepath.Join(dir, fmt.Sprintf("%020d%s", 1, segmentExt))
+	data, err := os.ReadFile(seg)
+	if err != nil {
+		t.Fatalf("read segment: %v", err)
+	}
+	recSize := headerSize + 1 + len(`{"n":1}`)
+	data[recSize+headerSize+2] ^= 0xff
+	if err := os.WriteFile(seg, data, 0o644); err != nil {
+		t.Fatalf("write segment: %v", err)
+	}
+
+	var keys []string
+	if _, err := s.Drain(context.Background(), collect(&keys)); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "d" {
+		t.Errorf("expected [a d] around the corrupt segment, got %v", keys)
+	}
+	if st := s.Stats(); st.Records != 0 || st.Bytes != 0 {
+		t.Errorf("expected the skipped records to leave the stats, got %+v", st)
+	}
+	if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("%020d%s", 1, quarantineExt))); err != nil {
+		t.Errorf("expected the segment to be kept as a quarantine file: %v", err)
+	}
+}
+
+func TestSpool_TornRecordOutsideCrashSegmentIsQuarantined(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := Open(dir, Options{SegmentBytes: 16}) // one record per segment
+	s.Append("a", []byte("{}"))
+	s.Append("b", []byte("{}"))
+	s.Close()
+
+	// Tear the end of segment 1, which was sealed before the process
+	// stopped.
+	seg := filepath.Join(dir, fmt.Sprintf("%020d%s", 1, segmentExt))
+	data, _ := os.ReadFile(seg)
+	os.WriteFile(seg, append(data, 0, 0, 0, 1), 0o644)
+
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+
+	var keys []string
+	if _, err := s.Drain(context.Background(), collect(&keys)); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("expected both intact records, got %v", keys)
+	}
+	if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("%020d%s", 1, quarantineExt))); err != nil {
+		t.Errorf("expected the torn sealed segment to be quarantined: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// APIKey is a stored API key. The key itself is never stored, only its
+// SHA-256 hash; Prefix is enough to recognise it.
+type APIKey struct {
+	ID        int64      `json:"id"`
+	TenantID  string     `json:"tenant_id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+const apiKeyColumns = `id, tenant_id, name, prefix, scopes, created_at, revoked_at`
+
+// CreateAPIKey stores a key by its hash.
+func (db *DB) CreateAPIKey(ctx context.Context, k APIKey, keyHash string) (*APIKey, error) {
+	query := `
+		INSERT INTO api_keys (tenant_id, name, prefix, key_hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING ` + apiKeyColumns
+	created, err := scanAPIKey(db.conn.QueryRowContext(ctx, query,
+		k.TenantID, k.Name, k.Prefix, keyHash, pq.Array(k.Scopes)))
+	if err != nil {
+		return nil, fmt.Errorf("insert api key for tenant %s: %w", k.TenantID, err)
+	}
+	return created, nil
+}
+
+// GetAPIKeyByHash returns the unrevoked key with the given hash, or nil
+// if there is none.
+func (db *DB) GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL"
+	k, err := scanAPIKey(db.conn.QueryRowContext(ctx, query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+	return k, nil
+}
+
+// ListAPIKeys returns the keys ctx may see, revoked ones included,
+// optionally only those of tenantID.
+func (db *DB) ListAPIKeys(ctx context.Context, tenantID string) ([]APIKey, error) {
+	filter, args, err := tenantFilter(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE 1=1" + filter
+	if tenantID != "" {
+		args = append(args, tenantID)
+		query += fmt.Sprintf(" AND tenant_id = $%d", len(args))
+	}
+	query += " ORDER BY id"
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes a key. It reports false if no unrevoked key that
+// ctx may see has that ID.
+func (db *DB) RevokeAPIKey(ctx context.Context, id int64) (bool, error) {
+	filter, args, err := tenantFilter(ctx, 2)
+	if err != nil {
+		return false, err
+	}
+	res, err := db.conn.ExecContext(ctx,
+		"UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL"+filter,
+		append([]interface{}{id}, args...)...)
+	if err != nil {
+		return false, fmt.Errorf("revoke api key %d: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("revoke api key %d: %w", id, err)
+	}
+	return n > 0, nil
+}
+
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	var k APIKey
+	if err := row.Scan(&k.ID, &k.TenantID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.CreatedAt, &k.RevokedAt); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tenant"
+)
+
+// DLQEvent represents a dead-lettered message persisted from the DLQ topic.
+type DLQEvent struct {
+	ID                int64           `json:"id"`
+	TenantID          string          `json:"tenant_id"` // parsed from OriginalValue; default if absent
+	OriginalTopic     string          `json:"original_topic"`
+	OriginalPartition int             `json:"original_partition"`
+	OriginalOffset    int64           `json:"original_offset"`
+	OriginalKey       string          `json:"original_key"`
+	OriginalValue     json.RawMessage `json:"original_value"`
+	EventType         string          `json:"event_type"` // parsed from OriginalValue; "" if unparseable
+	ErrorMessage      string          `json:"error_message"`
+	ErrorKind         string          `json:"error_kind"`
+	Retries           int             `json:"retries"`
+	FailedAt          time.Time       `json:"failed_at"`
+	ReceivedAt        time.Time       `json:"received_at"`
+	ReplayCount       int             `json:"replay_count"`
+	ReplayedAt        *time.Time      `json:"replayed_at,omitempty"`
+}
+
+// DLQFilter narrows a DLQ listing. Zero values mean "no filter".
+type DLQFilter struct {
+	ErrorKind     string
+	OriginalTopic string
+	EventType     string
+	From          *time.Time // failed_at >= From
+	To            *time.Time // failed_at <= To
+	Replayed      *bool      // true: replayed at least once; false: never replayed
+}
+
+const dlqColumns = `id, tenant_id, original_topic, original_partition, original_offset,
+	original_key, original_value, event_type,
+	error_message, error_kind, retries, failed_at, received_at,
+	replay_count, replayed_at`
+
+// InsertDLQEvent stores a DLQ envelope. The original topic/partition/offset
+// triple is unique, so redelivered envelopes are safely ignored.
+func (db *DB) InsertDLQEvent(ctx context.Context, e DLQEvent) error {
+	query := `
+		INSERT INTO dlq_events (
+			tenant_id, original_topic, original_partition, original_offset,
+			original_key, original_value, event_type,
+			error_message, error_kind, retries, failed_at, received_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		ON CONFLICT (original_topic, original_partition, original_offset) DO NOTHING
+	`
+	tenantID := e.TenantID
+	if tenantID == "" {
+		tenantID = tenant.Default
+	}
+	_, err := db.conn.ExecContext(ctx, query,
+		tenantID, e.OriginalTopic, e.OriginalPartition, e.OriginalOffset,
+		e.OriginalKey, []byte(e.OriginalValue), e.EventType,
+		e.ErrorMessage, e.ErrorKind, e.Retries, e.FailedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert dlq event %s/%d/%d: %w",
+			e.OriginalTopic, e.OriginalPartition, e.OriginalOffset, err)
+	}
+	return nil
+}
+
+// GetDLQEvents returns a paginated, filterable list of the context
+// tenant's DLQ events, most recent failures first.
+func (db *DB) GetDLQEvents(ctx context.Context, f DLQFilter, limit, offset int) ([]DLQEvent, int, error) {
+	where, args := f.where()
+	filter, tenantArgs, err := tenantFilter(ctx, len(args)+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	where += filter
+	args = append(args, tenantArgs...)
+	idx := len(args) + 1
+
+	countQuery := "SELECT COUNT(*) FROM dlq_events " + where
+	var total int
+	if err := db.conn.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count dlq events: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM dlq_events %s ORDER BY failed_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		dlqColumns, where, idx, idx+1,
+	)
+	args = append(args, limit, offset)
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query dlq events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []DLQEvent
+	for rows.Next() {
+		e, err := scanDLQEvent(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan dlq event: %w", err)
+		}
+		events = append(events, *e)
+	}
+	return events, total, rows.Err()
+}
+
+// GetDLQEvent returns a single DLQ event by ID, or nil if the context
+// tenant has no such event.
+func (db *DB) GetDLQEvent(ctx context.Context, id int64) (*DLQEvent, error) {
+	filter, args, err := tenantFilter(ctx, 2)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + dlqColumns + " FROM dlq_events WHERE id = $1" + filter
+	e, err := scanDLQEvent(db.conn.QueryRowContext(ctx, query, append([]interface{}{id}, args...)...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get dlq event %d: %w", id, err)
+	}
+	return e, nil
+}
+
+// MarkDLQEventReplayed records a replay of the given DLQ event and returns
+// the new replay count.
+func (db *DB) MarkDLQEventReplayed(ctx context.Context, id int64) (int, error) {
+	filter, args, err := tenantFilter(ctx, 2)
+	if err != nil {
+		return 0, err
+	}
+	query := `
+		UPDATE dlq_events
+		SET replay_count = replay_count + 1, replayed_at = NOW()
+		WHERE id = $1` + filter + `
+		RETURNING replay_count
+	`
+	var count int
+	if err := db.conn.QueryRowContext(ctx, query, append([]interface{}{id}, args...)...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("mark dlq event %d replayed: %w", id, err)
+	}
+	return count, nil
+}
+
+func (f DLQFilter) where() (string, []interface{}) {
+	where := "WHERE 1=1"
+	args := []interface{}{}
+	idx := 1
+
+	if f.ErrorKind != "" {
+		where += fmt.Sprintf(" AND error_kind = $%d", idx)
+		args = append(args, f.ErrorKind)
+		idx++
+	}
+	if f.OriginalTopic != "" {
+		where += fmt.Sprintf(" AND original_topic = $%d", idx)
+		args = append(args, f.OriginalTopic)
+		idx++
+	}
+	if f.EventType != "" {
+		where += fmt.Sprintf(" AND event_type = $%d", idx)
+		args = append(args, f.EventType)
+		idx++
+	}
+	if f.From != nil {
+		where += fmt.Sprintf(" AND failed_at >= $%d", idx)
+		args = append(args, *f.From)
+		idx++
+	}
+	if f.To != nil {
+		where += fmt.Sprintf(" AND failed_at <= $%d", idx)
+		args = append(args, *f.To)
+	}
+	if f.Replayed != nil {
+		if *f.Replayed {
+			where += " AND replayed_at IS NOT NULL"
+		} else {
+			where += " AND replayed_at IS NULL"
+		}
+	}
+	return where, args
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDLQEvent(row rowScanner) (*DLQEvent, error) {
+	var e DLQEvent
+	var value []byte
+	err := row.Scan(
+		&e.ID, &e.TenantID, &e.OriginalTopic, &e.OriginalPartition, &e.OriginalOffset,
+		&e.OriginalKey, &value, &e.EventType,
+		&e.ErrorMessage, &e.ErrorKind, &e.Retries, &e.FailedAt, &e.ReceivedAt,
+		&e.ReplayCount, &e.ReplayedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	e.OriginalValue = value
+	return &e, nil
+}
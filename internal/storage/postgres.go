@@ -5,15 +5,41 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/metrics"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tenant"
+	"github.com/Karthik0000007/Event_Analytics_Platform/internal/tracing"
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type DB struct {
 	conn *sql.DB
 }
 
+// TimeAxis selects which timestamp event queries filter and bucket on.
+type TimeAxis string
+
+const (
+	// AxisReceived uses received_at, when the consumer stored the event.
+	AxisReceived TimeAxis = "received"
+	// AxisOccurred uses the client-supplied occurred_at, falling back to
+	// received_at for events sent without one.
+	AxisOccurred TimeAxis = "occurred"
+)
+
+// column returns the SQL expression for the axis. Unknown values map to
+// received_at so callers can pass user input straight through.
+func (a TimeAxis) column() string {
+	if a == AxisOccurred {
+		return "COALESCE(occurred_at, received_at)"
+	}
+	return "received_at"
+}
+
 // New opens a connection pool to PostgreSQL and verifies connectivity.
 func New(dsn string) (*DB, error) {
 	conn, err := sql.Open("postgres", dsn)
@@ -35,26 +61,113 @@ func New(dsn string) (*DB, error) {
 	return &DB{conn: conn}, nil
 }
 
-// InsertEvent performs an idempotent upsert keyed on event_id (PRIMARY KEY).
+// InsertEvent performs an idempotent upsert keyed on (tenant_id, event_id),
+// the PRIMARY KEY.
 // Duplicate replays are safely ignored via ON CONFLICT DO NOTHING.
-func (db *DB) InsertEvent(ctx context.Context, eventID, eventType string, payload json.RawMessage) error {
+func (db *DB) InsertEvent(ctx context.Context, e Event) (err error) {
+	ctx, span := startInsertSpan(ctx, "InsertEvent", 1)
+	defer func() { tracing.End(span, err) }()
+
 	query := `
-		INSERT INTO events (event_id, event_type, payload, received_at)
-		VALUES ($1, $2, $3, NOW())
-		ON CONFLICT (event_id) DO NOTHING
+		INSERT INTO events (event_id, tenant_id, event_type, payload, schema_version, occurred_at, received_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (tenant_id, event_id) DO NOTHING
 	`
-	_, err := db.conn.ExecContext(ctx, query, eventID, eventType, payload)
+	start := time.Now()
+	_, err = db.conn.ExecContext(ctx, query, e.EventID, e.tenant(), e.EventType, e.Payload, e.SchemaVersion, e.OccurredAt)
+	metrics.InsertDuration.WithLabelValues("row").Observe(time.Since(start).Seconds())
+	if err != nil {
+		return fmt.Errorf("insert event %s: %w", e.EventID, err)
+	}
+	return nil
+}
+
+// MaxInsertBatch is the most rows InsertEvents writes in one statement,
+// keeping the bind parameters under PostgreSQL's limit of 65535.
+const MaxInsertBatch = 10000
+
+// InsertEvents writes events with a single multi-row INSERT. Like
+// InsertEvent it is idempotent: rows whose key already exists, or
+// repeats within the batch, are skipped. The statement is atomic, so one
+// bad row fails the whole batch.
+func (db *DB) InsertEvents(ctx context.Context, events []Event) (err error) {
+	if len(events) == 0 {
+		return nil
+	}
+	if len(events) > MaxInsertBatch {
+		return fmt.Errorf("insert events: batch of %d exceeds %d rows", len(events), MaxInsertBatch)
+	}
+	ctx, span := startInsertSpan(ctx, "InsertEvents", len(events))
+	defer func() { tracing.End(span, err) }()
+
+	var b strings.Builder
+	b.WriteString("INSERT INTO events (event_id, tenant_id, event_type, payload, schema_version, occurred_at, received_at) VALUES ")
+	args := make([]interface{}, 0, len(events)*6)
+	for i, e := range events {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n := i * 6
+		fmt.Fprintf(&b, "($%d, $%d, $%d, $%d, $%d, $%d, NOW())", n+1, n+2, n+3, n+4, n+5, n+6)
+		args = append(args, e.EventID, e.tenant(), e.EventType, e.Payload, e.SchemaVersion, e.OccurredAt)
+	}
+	b.WriteString(" ON CONFLICT (tenant_id, event_id) DO NOTHING")
+
+	start := time.Now()
+	_, err = db.conn.ExecContext(ctx, b.String(), args...)
+	metrics.InsertDuration.WithLabelValues("batch").Observe(time.Since(start).Seconds())
 	if err != nil {
-		return fmt.Errorf("insert event %s: %w", eventID, err)
+		return fmt.Errorf("insert %d events: %w", len(events), err)
 	}
 	return nil
 }
 
-// GetEvents returns a paginated, filterable list of events.
-func (db *DB) GetEvents(ctx context.Context, eventType string, from, to *time.Time, limit, offset int) ([]Event, int, error) {
-	where := "WHERE 1=1"
-	args := []interface{}{}
-	idx := 1
+// startInsertSpan starts a client span for a write to the events table.
+func startInsertSpan(ctx context.Context, name string, rows int) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", "INSERT"),
+			attribute.String("db.collection.name", "events"),
+			attribute.Int("db.operation.batch.size", rows),
+		),
+	)
+}
+
+// tenantFilter returns a " AND tenant_id = $idx" clause and its argument
+// for the tenant ctx acts for, or nothing if ctx may read every tenant.
+// Every read of a tenant-owned table goes through it.
+func tenantFilter(ctx context.Context, idx int) (string, []interface{}, error) {
+	id, all, err := tenant.Scope(ctx)
+	if err != nil || all {
+		return "", nil, err
+	}
+	return fmt.Sprintf(" AND tenant_id = $%d", idx), []interface{}{id}, nil
+}
+
+// eventColumns is the column list scanned by scanEvent.
+const eventColumns = "event_id, tenant_id, event_type, payload, schema_version, occurred_at, received_at"
+
+func scanEvent(row rowScanner) (*Event, error) {
+	var e Event
+	err := row.Scan(&e.EventID, &e.TenantID, &e.EventType, &e.Payload, &e.SchemaVersion, &e.OccurredAt, &e.ReceivedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetEvents returns a paginated, filterable list of the context tenant's
+// events. The from/to range and ordering apply to the given time axis.
+func (db *DB) GetEvents(ctx context.Context, eventType string, axis TimeAxis, from, to *time.Time, limit, offset int) ([]Event, int, error) {
+	ts := axis.column()
+	where, args, err := tenantFilter(ctx, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	where = "WHERE 1=1" + where
+	idx := len(args) + 1
 
 	if eventType != "" {
 		where += fmt.Sprintf(" AND event_type = $%d", idx)
@@ -62,12 +175,12 @@ func (db *DB) GetEvents(ctx context.Context, eventType string, from, to *time.Ti
 		idx++
 	}
 	if from != nil {
-		where += fmt.Sprintf(" AND received_at >= $%d", idx)
+		where += fmt.Sprintf(" AND %s >= $%d", ts, idx)
 		args = append(args, *from)
 		idx++
 	}
 	if to != nil {
-		where += fmt.Sprintf(" AND received_at <= $%d", idx)
+		where += fmt.Sprintf(" AND %s <= $%d", ts, idx)
 		args = append(args, *to)
 		idx++
 	}
@@ -81,8 +194,8 @@ func (db *DB) GetEvents(ctx context.Context, eventType string, from, to *time.Ti
 
 	// Fetch page
 	query := fmt.Sprintf(
-		"SELECT event_id, event_type, payload, received_at FROM events %s ORDER BY received_at DESC LIMIT $%d OFFSET $%d",
-		where, idx, idx+1,
+		"SELECT %s FROM events %s ORDER BY %s DESC LIMIT $%d OFFSET $%d",
+		eventColumns, where, ts, idx, idx+1,
 	)
 	args = append(args, limit, offset)
 
@@ -94,27 +207,35 @@ func (db *DB) GetEvents(ctx context.Context, eventType string, from, to *time.Ti
 
 	var events []Event
 	for rows.Next() {
-		var e Event
-		if err := rows.Scan(&e.EventID, &e.EventType, &e.Payload, &e.ReceivedAt); err != nil {
+		e, err := scanEvent(rows)
+		if err != nil {
 			return nil, 0, fmt.Errorf("scan event: %w", err)
 		}
-		events = append(events, e)
+		events = append(events, *e)
 	}
 	return events, total, rows.Err()
 }
 
-// GetEvent returns a single event by ID.
+// GetEvent returns a single event by ID, or nil if the context tenant
+// has no such event. Event IDs are only unique within a tenant, so a
+// context created with tenant.WithAll gets tenant.ErrAllTenants.
 func (db *DB) GetEvent(ctx context.Context, eventID string) (*Event, error) {
-	query := `SELECT event_id, event_type, payload, received_at FROM events WHERE event_id = $1`
-	var e Event
-	err := db.conn.QueryRowContext(ctx, query, eventID).Scan(&e.EventID, &e.EventType, &e.Payload, &e.ReceivedAt)
+	id, all, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return nil, tenant.ErrAllTenants
+	}
+	query := "SELECT " + eventColumns + " FROM events WHERE event_id = $1 AND tenant_id = $2"
+	e, err := scanEvent(db.conn.QueryRowContext(ctx, query, eventID, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("get event %s: %w", eventID, err)
 	}
-	return &e, nil
+	return e, nil
 }
 
 // Summary holds aggregate stats.
@@ -125,21 +246,25 @@ type Summary struct {
 	TopTypes    []string `json:"top_types"`
 }
 
-// GetSummary returns aggregate statistics.
+// GetSummary returns aggregate statistics for the context tenant.
 func (db *DB) GetSummary(ctx context.Context) (*Summary, error) {
+	filter, args, err := tenantFilter(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
 	s := &Summary{}
 
-	if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM events").Scan(&s.TotalEvents); err != nil {
+	if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM events WHERE TRUE"+filter, args...).Scan(&s.TotalEvents); err != nil {
 		return nil, fmt.Errorf("count total: %w", err)
 	}
-	if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM events WHERE received_at >= CURRENT_DATE").Scan(&s.TodayEvents); err != nil {
+	if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM events WHERE received_at >= CURRENT_DATE"+filter, args...).Scan(&s.TodayEvents); err != nil {
 		return nil, fmt.Errorf("count today: %w", err)
 	}
-	if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(DISTINCT event_type) FROM events").Scan(&s.EventTypes); err != nil {
+	if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(DISTINCT event_type) FROM events WHERE TRUE"+filter, args...).Scan(&s.EventTypes); err != nil {
 		return nil, fmt.Errorf("count types: %w", err)
 	}
 
-	rows, err := db.conn.QueryContext(ctx, "SELECT event_type FROM events GROUP BY event_type ORDER BY COUNT(*) DESC LIMIT 5")
+	rows, err := db.conn.QueryContext(ctx, "SELECT event_type FROM events WHERE TRUE"+filter+" GROUP BY event_type ORDER BY COUNT(*) DESC LIMIT 5", args...)
 	if err != nil {
 		return nil, fmt.Errorf("top types: %w", err)
 	}
@@ -160,9 +285,13 @@ type TypeCount struct {
 	Count     int    `json:"count"`
 }
 
-// GetTypeCounts returns event counts grouped by type.
+// GetTypeCounts returns the context tenant's event counts grouped by type.
 func (db *DB) GetTypeCounts(ctx context.Context) ([]TypeCount, error) {
-	rows, err := db.conn.QueryContext(ctx, "SELECT event_type, COUNT(*) FROM events GROUP BY event_type ORDER BY COUNT(*) DESC")
+	filter, args, err := tenantFilter(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.conn.QueryContext(ctx, "SELECT event_type, COUNT(*) FROM events WHERE TRUE"+filter+" GROUP BY event_type ORDER BY COUNT(*) DESC", args...)
 	if err != nil {
 		return nil, fmt.Errorf("type counts: %w", err)
 	}
@@ -184,16 +313,22 @@ type TimelinePoint struct {
 	Count  int       `json:"count"`
 }
 
-// GetTimeline returns event counts grouped by hour for the last 24 hours.
-func (db *DB) GetTimeline(ctx context.Context, hours int) ([]TimelinePoint, error) {
-	query := `
-		SELECT date_trunc('hour', received_at) AS bucket, COUNT(*)
+// GetTimeline returns the context tenant's event counts grouped by hour
+// for the last hours, bucketed on the given time axis.
+func (db *DB) GetTimeline(ctx context.Context, axis TimeAxis, hours int) ([]TimelinePoint, error) {
+	filter, args, err := tenantFilter(ctx, 2)
+	if err != nil {
+		return nil, err
+	}
+	ts := axis.column()
+	query := fmt.Sprintf(`
+		SELECT date_trunc('hour', %[1]s) AS bucket, COUNT(*)
 		FROM events
-		WHERE received_at >= NOW() - ($1 || ' hours')::INTERVAL
+		WHERE %[1]s >= NOW() - ($1 || ' hours')::INTERVAL%[2]s
 		GROUP BY bucket
 		ORDER BY bucket
-	`
-	rows, err := db.conn.QueryContext(ctx, query, fmt.Sprintf("%d", hours))
+	`, ts, filter)
+	rows, err := db.conn.QueryContext(ctx, query, append([]interface{}{fmt.Sprintf("%d", hours)}, args...)...)
 	if err != nil {
 		return nil, fmt.Errorf("timeline: %w", err)
 	}
@@ -211,10 +346,39 @@ func (db *DB) GetTimeline(ctx context.Context, hours int) ([]TimelinePoint, erro
 
 // Event represents a stored event row.
 type Event struct {
-	EventID    string          `json:"event_id"`
-	EventType  string          `json:"event_type"`
-	Payload    json.RawMessage `json:"payload"`
-	ReceivedAt time.Time       `json:"received_at"`
+	EventID       string          `json:"event_id"`
+	TenantID      string          `json:"tenant_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	SchemaVersion *int            `json:"schema_version,omitempty"` // nil if no schema was active
+	OccurredAt    *time.Time      `json:"occurred_at,omitempty"`    // client timestamp, if supplied
+	ReceivedAt    time.Time       `json:"received_at"`
+}
+
+// CountEventsSince returns how many events tenantID has stored since the
+// given time. It backs per-tenant quotas.
+func (db *DB) CountEventsSince(ctx context.Context, tenantID string, since time.Time) (int64, error) {
+	var n int64
+	err := db.conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM events WHERE tenant_id = $1 AND received_at >= $2", tenantID, since).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count events for tenant %s: %w", tenantID, err)
+	}
+	return n, nil
+}
+
+// tenant returns the owning tenant, defaulting rows from untenanted
+// messages.
+func (e Event) tenant() string {
+	if e.TenantID == "" {
+		return tenant.Default
+	}
+	return e.TenantID
+}
+
+// Ping verifies the database is reachable.
+func (db *DB) Ping(ctx context.Context) error {
+	return db.conn.PingContext(ctx)
 }
 
 // Close shuts down the connection pool.
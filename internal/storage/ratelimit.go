@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// refilledTokens is the bucket's tokens after refilling at $2 per second
+// up to $3 since its last update.
+const refilledTokens = `LEAST($3::float8, b.tokens + $2::float8 * EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8)`
+
+// TakeTokens refills the token bucket named key at rate tokens per second
+// up to burst and removes n tokens if it holds that many. A new bucket
+// starts full. It returns the tokens left and whether n were removed. The
+// single statement serialises concurrent takes on the row lock.
+func (db *DB) TakeTokens(ctx context.Context, key string, rate, burst, n float64) (float64, bool, error) {
+	query := `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at, full_at)
+		VALUES ($1,
+			CASE WHEN $3::float8 >= $4::float8 THEN $3::float8 - $4::float8 ELSE $3::float8 END,
+			$3::float8 >= $4::float8, NOW(), NOW() + make_interval(secs => $3::float8 / $2::float8))
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE WHEN ` + refilledTokens + ` >= $4::float8
+				THEN ` + refilledTokens + ` - $4::float8
+				ELSE ` + refilledTokens + ` END,
+			allowed = ` + refilledTokens + ` >= $4::float8,
+			updated_at = NOW(),
+			full_at = NOW() + make_interval(secs => $3::float8 / $2::float8)
+		RETURNING tokens, allowed
+	`
+	var tokens float64
+	var allowed bool
+	if err := db.conn.QueryRowContext(ctx, query, key, rate, burst, n).Scan(&tokens, &allowed); err != nil {
+		return 0, false, fmt.Errorf("take tokens from %s: %w", key, err)
+	}
+	return tokens, allowed, nil
+}
+
+// DeleteRefilledBuckets removes token buckets that are full again.
+// full_at assumes an empty bucket, so no bucket is removed early.
+func (db *DB) DeleteRefilledBuckets(ctx context.Context) (int64, error) {
+	res, err := db.conn.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE full_at <= NOW()")
+	if err != nil {
+		return 0, fmt.Errorf("delete refilled rate limit buckets: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ReturnTokens refills the token bucket named key like TakeTokens and
+// puts back n tokens, up to burst. A bucket that does not exist is full
+// already and is left alone.
+func (db *DB) ReturnTokens(ctx context.Context, key string, rate, burst, n float64) error {
+	query := `
+		UPDATE rate_limit_buckets AS b SET
+			tokens = LEAST($3::float8, ` + refilledTokens + ` + $4::float8),
+			updated_at = NOW()
+		WHERE key = $1
+	`
+	if _, err := db.conn.ExecContext(ctx, query, key, rate, burst, n); err != nil {
+		return fmt.Errorf("return tokens to %s: %w", key, err)
+	}
+	return nil
+}
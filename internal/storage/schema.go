@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSchemaActive is returned when deleting the active schema version.
+var ErrSchemaActive = errors.New("schema version is active")
+
+// EventSchema is one version of the JSON Schema for an event type.
+type EventSchema struct {
+	EventType string          `json:"event_type"`
+	Version   int             `json:"version"`
+	Schema    json.RawMessage `json:"schema"`
+	Active    bool            `json:"active"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+const schemaColumns = `event_type, version, schema, active, created_at`
+
+// CreateSchema stores schema as the next version for eventType and, if
+// activate is set, makes it the active version.
+func (db *DB) CreateSchema(ctx context.Context, eventType string, schema json.RawMessage, activate bool) (*EventSchema, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin create schema: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialise version allocation per event type.
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", eventType); err != nil {
+		return nil, fmt.Errorf("lock schema %s: %w", eventType, err)
+	}
+	if activate {
+		if _, err := tx.ExecContext(ctx, "UPDATE event_schemas SET active = FALSE WHERE event_type = $1 AND active", eventType); err != nil {
+			return nil, fmt.Errorf("deactivate schema %s: %w", eventType, err)
+		}
+	}
+
+	query := `
+		INSERT INTO event_schemas (event_type, version, schema, active, created_at)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, NOW()
+		FROM event_schemas WHERE event_type = $1
+		RETURNING ` + schemaColumns
+	s, err := scanSchema(tx.QueryRowContext(ctx, query, eventType, []byte(schema), activate))
+	if err != nil {
+		return nil, fmt.Errorf("insert schema %s: %w", eventType, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit schema %s: %w", eventType, err)
+	}
+	return s, nil
+}
+
+// ListSchemas returns all schema versions, optionally for one event type.
+func (db *DB) ListSchemas(ctx context.Context, eventType string) ([]EventSchema, error) {
+	query := "SELECT " + schemaColumns + " FROM event_schemas"
+	args := []interface{}{}
+	if eventType != "" {
+		query += " WHERE event_type = $1"
+		args = append(args, eventType)
+	}
+	query += " ORDER BY event_type, version"
+	return db.querySchemas(ctx, query, args...)
+}
+
+// GetActiveSchemas returns the active schema of every event type.
+func (db *DB) GetActiveSchemas(ctx context.Context) ([]EventSchema, error) {
+	return db.querySchemas(ctx, "SELECT "+schemaColumns+" FROM event_schemas WHERE active")
+}
+
+// GetSchema returns one schema version, or nil if it does not exist.
+func (db *DB) GetSchema(ctx context.Context, eventType string, version int) (*EventSchema, error) {
+	query := "SELECT " + schemaColumns + " FROM event_schemas WHERE event_type = $1 AND version = $2"
+	s, err := scanSchema(db.conn.QueryRowContext(ctx, query, eventType, version))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get schema %s v%d: %w", eventType, version, err)
+	}
+	return s, nil
+}
+
+// ActivateSchema makes version the active schema for eventType. It
+// returns false if the version does not exist.
+func (db *DB) ActivateSchema(ctx context.Context, eventType string, version int) (bool, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin activate schema: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", eventType); err != nil {
+		return false, fmt.Errorf("lock schema %s: %w", eventType, err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE event_schemas SET active = FALSE WHERE event_type = $1 AND active", eventType); err != nil {
+		return false, fmt.Errorf("deactivate schema %s: %w", eventType, err)
+	}
+	res, err := tx.ExecContext(ctx, "UPDATE event_schemas SET active = TRUE WHERE event_type = $1 AND version = $2", eventType, version)
+	if err != nil {
+		return false, fmt.Errorf("activate schema %s v%d: %w", eventType, version, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit activate schema: %w", err)
+	}
+	return true, nil
+}
+
+// DeactivateSchema turns off validation for eventType.
+func (db *DB) DeactivateSchema(ctx context.Context, eventType string) error {
+	if _, err := db.conn.ExecContext(ctx, "UPDATE event_schemas SET active = FALSE WHERE event_type = $1 AND active", eventType); err != nil {
+		return fmt.Errorf("deactivate schema %s: %w", eventType, err)
+	}
+	return nil
+}
+
+// DeleteSchema removes an inactive schema version. It returns false if
+// the version does not exist and ErrSchemaActive if it is active.
+func (db *DB) DeleteSchema(ctx context.Context, eventType string, version int) (bool, error) {
+	var active bool
+	err := db.conn.QueryRowContext(ctx,
+		"DELETE FROM event_schemas WHERE event_type = $1 AND version = $2 AND NOT active RETURNING active",
+		eventType, version,
+	).Scan(&active)
+	if err == nil {
+		return true, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, fmt.Errorf("delete schema %s v%d: %w", eventType, version, err)
+	}
+
+	existing, err := db.GetSchema(ctx, eventType, version)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		return false, ErrSchemaActive
+	}
+	return false, nil
+}
+
+func (db *DB) querySchemas(ctx context.Context, query string, args ...interface{}) ([]EventSchema, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var schemas []EventSchema
+	for rows.Next() {
+		s, err := scanSchema(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan schema: %w", err)
+		}
+		schemas = append(schemas, *s)
+	}
+	return schemas, rows.Err()
+}
+
+func scanSchema(row rowScanner) (*EventSchema, error) {
+	var s EventSchema
+	var schema []byte
+	if err := row.Scan(&s.EventType, &s.Version, &schema, &s.Active, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	s.Schema = schema
+	return &s, nil
+}
@@ -0,0 +1,55 @@
+// Package tenant carries the tenant a request acts for. Storage reads
+// filter on it, so a caller only ever sees its own tenant's rows.
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+// Default owns events ingested without authentication and messages
+// produced before tenants existed.
+const Default = "default"
+
+// ErrMissing is returned by storage reads whose context names no tenant.
+var ErrMissing = errors.New("no tenant in context")
+
+// ErrAllTenants is returned by lookups keyed by an ID that is only unique
+// within a tenant when their context was created with WithAll.
+var ErrAllTenants = errors.New("lookup needs a single tenant")
+
+type ctxKey struct{}
+
+// allTenants marks a context that may read every tenant's rows.
+type allTenants struct{}
+
+// With returns ctx acting for tenant id.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// WithAll returns ctx allowed to read across tenants. Only operator
+// tooling should use it; HTTP requests always act for one tenant.
+func WithAll(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKey{}, allTenants{})
+}
+
+// From returns the tenant ctx acts for. ok is false when ctx names no
+// tenant or was created with WithAll.
+func From(ctx context.Context) (id string, ok bool) {
+	id, ok = ctx.Value(ctxKey{}).(string)
+	return id, ok
+}
+
+// Scope reports which rows a read from ctx may see: one tenant's, or
+// every tenant's when all is true. It fails with ErrMissing if ctx was
+// given neither.
+func Scope(ctx context.Context) (id string, all bool, err error) {
+	switch v := ctx.Value(ctxKey{}).(type) {
+	case string:
+		return v, false, nil
+	case allTenants:
+		return "", true, nil
+	}
+	return "", false, ErrMissing
+}
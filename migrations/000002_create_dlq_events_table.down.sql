@@ -0,0 +1 @@
+DROP TABLE IF EXISTS dlq_events;
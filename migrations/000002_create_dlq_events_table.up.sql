@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS dlq_events (
+    id                 BIGSERIAL PRIMARY KEY,
+    original_topic     VARCHAR(255) NOT NULL,
+    original_partition INTEGER NOT NULL,
+    original_offset    BIGINT NOT NULL,
+    original_key       TEXT NOT NULL DEFAULT '',
+    original_value     BYTEA NOT NULL,
+    error_message      TEXT NOT NULL,
+    error_kind         VARCHAR(32) NOT NULL,
+    retries            INTEGER NOT NULL DEFAULT 0,
+    failed_at          TIMESTAMPTZ NOT NULL,
+    received_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (original_topic, original_partition, original_offset)
+);
+
+CREATE INDEX idx_dlq_events_failed_at ON dlq_events (failed_at);
+CREATE INDEX idx_dlq_events_error_kind ON dlq_events (error_kind);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_dlq_events_original_topic;
+DROP INDEX IF EXISTS idx_dlq_events_event_type;
+ALTER TABLE dlq_events DROP COLUMN IF EXISTS event_type;
@@ -0,0 +1,4 @@
+ALTER TABLE dlq_events ADD COLUMN IF NOT EXISTS event_type VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_dlq_events_event_type ON dlq_events (event_type);
+CREATE INDEX idx_dlq_events_original_topic ON dlq_events (original_topic);
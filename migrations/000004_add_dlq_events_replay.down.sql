@@ -0,0 +1,2 @@
+ALTER TABLE dlq_events DROP COLUMN IF EXISTS replayed_at;
+ALTER TABLE dlq_events DROP COLUMN IF EXISTS replay_count;
@@ -0,0 +1,2 @@
+ALTER TABLE dlq_events ADD COLUMN IF NOT EXISTS replay_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE dlq_events ADD COLUMN IF NOT EXISTS replayed_at TIMESTAMPTZ;
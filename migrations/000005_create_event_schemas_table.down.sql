@@ -0,0 +1,2 @@
+ALTER TABLE events DROP COLUMN IF EXISTS schema_version;
+DROP TABLE IF EXISTS event_schemas;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS event_schemas (
+    event_type  VARCHAR(255) NOT NULL,
+    version     INTEGER NOT NULL,
+    schema      JSONB NOT NULL,
+    active      BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_type, version)
+);
+
+-- At most one active schema per event type.
+CREATE UNIQUE INDEX idx_event_schemas_active ON event_schemas (event_type) WHERE active;
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS schema_version INTEGER;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_events_occurred_at;
+ALTER TABLE events DROP COLUMN IF EXISTS occurred_at;
@@ -0,0 +1,5 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS occurred_at TIMESTAMPTZ;
+
+-- Queries on the "occurred" time axis fall back to received_at for
+-- events sent without a client timestamp.
+CREATE INDEX idx_events_occurred_at ON events ((COALESCE(occurred_at, received_at)));
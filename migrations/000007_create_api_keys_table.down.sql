@@ -0,0 +1,3 @@
+ALTER TABLE dlq_events DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE events DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,18 @@
+-- Only the SHA-256 of a key is stored; the plaintext is shown once, when
+-- the key is created. prefix lets operators recognise a key in listings.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           BIGSERIAL PRIMARY KEY,
+    tenant_id    VARCHAR(64) NOT NULL,
+    name         VARCHAR(255) NOT NULL,
+    prefix       VARCHAR(16) NOT NULL,
+    key_hash     CHAR(64) NOT NULL UNIQUE,
+    scopes       TEXT[] NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX idx_api_keys_tenant ON api_keys (tenant_id);
+
+-- Rows written before tenants existed belong to the default tenant.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE dlq_events ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
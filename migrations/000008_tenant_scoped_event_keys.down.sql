@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_dlq_events_tenant_failed_at;
+DROP INDEX IF EXISTS idx_events_tenant_occurred_at;
+DROP INDEX IF EXISTS idx_events_tenant_type_received_at;
+DROP INDEX IF EXISTS idx_events_tenant_received_at;
+
+-- Fails if two tenants stored the same event_id.
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_pkey;
+ALTER TABLE events ADD PRIMARY KEY (event_id);
@@ -0,0 +1,10 @@
+-- event_id is unique per tenant, not globally: two tenants sending the
+-- same ID must not silently drop each other's events.
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_pkey;
+ALTER TABLE events ADD PRIMARY KEY (tenant_id, event_id);
+
+-- Every read filters on tenant_id first.
+CREATE INDEX idx_events_tenant_received_at ON events (tenant_id, received_at);
+CREATE INDEX idx_events_tenant_type_received_at ON events (tenant_id, event_type, received_at);
+CREATE INDEX idx_events_tenant_occurred_at ON events (tenant_id, (COALESCE(occurred_at, received_at)));
+CREATE INDEX idx_dlq_events_tenant_failed_at ON dlq_events (tenant_id, failed_at);
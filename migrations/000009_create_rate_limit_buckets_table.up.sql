@@ -0,0 +1,11 @@
+-- Token buckets shared by API replicas. UNLOGGED: losing them in a crash
+-- only refills every bucket, which is not worth WAL traffic per request.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key        VARCHAR(255) PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    allowed    BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    full_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_full_at ON rate_limit_buckets (full_at);